@@ -2,20 +2,45 @@ package main
 
 import (
 	"flag"
-	"fmt"
 	"log"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"syscall"
 
 	"github.com/solacedev/restv2-api-server-go/internal/server"
 )
 
+// defaultRulesDir is the rules directory of a checkout, loaded when no rules
+// directory is given so that the default rules can be edited without a rebuild
+const defaultRulesDir = "config/rules"
+
+// stringList is a flag that can be repeated to collect several values
+type stringList []string
+
+// String returns the values of the flag
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+// Set adds a value to the flag
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
 func main() {
+	// All logging goes to stderr so it never corrupts the stdio protocol stream
+	log.SetOutput(os.Stderr)
+
 	// Parse command line flags
 	port := flag.Int("port", 9090, "The port to listen on")
 	keepAlive := flag.Bool("keep-alive", false, "Enable keep-alive mechanism")
+	transport := flag.String("transport", "http", "The transport to serve MCP on (stdio or http)")
+	var rulesDirs stringList
+	flag.Var(&rulesDirs, "rules-dir", "A directory of JSON rules layered on top of the default rules (repeatable, later directories take precedence)")
 	flag.Parse()
 
 	// Check environment variables
@@ -29,8 +54,27 @@ func main() {
 		*keepAlive = true
 	}
 
+	if envTransport := os.Getenv("MCP_TRANSPORT"); envTransport != "" {
+		*transport = envTransport
+	}
+
+	// Directories from the environment take precedence over those from flags
+	if envRulesDirs := os.Getenv("MCP_RULES_DIR"); envRulesDirs != "" {
+		rulesDirs = append(rulesDirs, filepath.SplitList(envRulesDirs)...)
+	}
+
+	if len(rulesDirs) == 0 {
+		if info, err := os.Stat(defaultRulesDir); err == nil && info.IsDir() {
+			rulesDirs = append(rulesDirs, defaultRulesDir)
+		}
+	}
+
+	if *transport != "stdio" && *transport != "http" {
+		log.Fatalf("Unknown transport %q (expected stdio or http)", *transport)
+	}
+
 	// Create a new server
-	s, err := server.NewServer(*port, *keepAlive)
+	s, err := server.NewServer(*port, *keepAlive, rulesDirs)
 	if err != nil {
 		log.Fatalf("Error creating server: %v", err)
 	}
@@ -42,9 +86,15 @@ func main() {
 	// Start the server in a goroutine
 	errChan := make(chan error)
 	go func() {
-		fmt.Printf("Starting REST API Validator MCP server on port %d...\n", *port)
+		if *transport == "stdio" {
+			log.Println("Starting REST API Validator MCP server on stdio...")
+			errChan <- s.ServeStdio(os.Stdin, os.Stdout)
+			return
+		}
+
+		log.Printf("Starting REST API Validator MCP server on port %d...", *port)
 		if *keepAlive {
-			fmt.Println("Keep-alive mechanism enabled")
+			log.Println("Keep-alive mechanism enabled")
 		}
 		errChan <- s.Start()
 	}()
@@ -52,15 +102,15 @@ func main() {
 	// Wait for termination signal or server error
 	select {
 	case sig := <-sigChan:
-		fmt.Printf("Received signal %v, shutting down...\n", sig)
+		log.Printf("Received signal %v, shutting down...", sig)
 	case err := <-errChan:
 		if err != nil {
-			fmt.Printf("Server error: %v\n", err)
+			log.Printf("Server error: %v", err)
 		}
 	}
 
 	// Shutdown the server
 	if err := s.Shutdown(); err != nil {
-		fmt.Printf("Error shutting down server: %v\n", err)
+		log.Printf("Error shutting down server: %v", err)
 	}
 }
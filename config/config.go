@@ -0,0 +1,13 @@
+// Package config holds the configuration shipped with the server
+package config
+
+import "embed"
+
+// DefaultRules holds the default JSON rules and profiles, so they are
+// available regardless of the working directory the server is started from
+//
+//go:embed rules/*.json rules/profiles/*.json
+var DefaultRules embed.FS
+
+// DefaultRulesDir is the directory of the default rules within DefaultRules
+const DefaultRulesDir = "rules"
@@ -1,10 +1,12 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
 
+	"github.com/solacedev/restv2-api-server-go/internal/rules"
 	"github.com/solacedev/restv2-api-server-go/internal/validator"
 )
 
@@ -25,7 +27,7 @@ func main() {
 	}
 
 	// Validate the API spec with specific focus on audit fields
-	results, err := v.Validate(map[string]interface{}{
+	results, err := v.Validate(context.Background(), map[string]interface{}{
 		"api_spec": apiSpecFile,
 		"rules":    []interface{}{"audit_fields"},
 	})
@@ -43,13 +45,13 @@ func main() {
 	fmt.Println(string(resultsJSON))
 
 	// Check if validation passed
-	auditFieldsResult, ok := results["results"].(map[string]interface{})["audit_fields"].(map[string]interface{})
+	auditFieldsResult, ok := results["results"].(map[string]*rules.Result)["audit_fields"]
 	if !ok {
 		fmt.Println("Error: audit_fields result not found")
 		os.Exit(1)
 	}
 
-	if auditFieldsResult["status"] == "passed" {
+	if auditFieldsResult.Status == rules.StatusPassed {
 		fmt.Println("Audit fields validation successful!")
 	} else {
 		fmt.Println("Audit fields validation failed!")
@@ -64,7 +64,7 @@ func testPing(url string) error {
 		return fmt.Errorf("missing result in response")
 	}
 
-	if result != "pong" {
+	if object, ok := result.(map[string]interface{}); !ok || len(object) != 0 {
 		return fmt.Errorf("unexpected result: %v", result)
 	}
 
@@ -148,8 +148,8 @@ func pingServer(url string) error {
 		return fmt.Errorf("missing result in response")
 	}
 
-	// Check if the result is "pong"
-	if result != "pong" {
+	// Check that the result is an empty object
+	if object, ok := result.(map[string]interface{}); !ok || len(object) != 0 {
 		return fmt.Errorf("unexpected result: %v", result)
 	}
 
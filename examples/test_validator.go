@@ -1,19 +1,22 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"flag"
 	"fmt"
-	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 
+	"github.com/solacedev/restv2-api-server-go/internal/rules"
 	"github.com/solacedev/restv2-api-server-go/internal/validator"
 )
 
 func main() {
 	// Parse command line flags
 	apiSpecPath := flag.String("api-spec", "", "Path to the API specification file")
+	ruleNames := flag.String("rules", "", "Comma-separated names of the rules to validate against (default: all)")
 	flag.Parse()
 
 	// If no API spec path provided, use the first argument
@@ -24,7 +27,7 @@ func main() {
 	// Check if API spec path is provided
 	if *apiSpecPath == "" {
 		fmt.Println("Error: API specification file path is required")
-		fmt.Println("Usage: go run test_validator.go [--api-spec] <path-to-api-spec>")
+		fmt.Println("Usage: go run test_validator.go [--rules <rule,...>] [--api-spec] <path-to-api-spec>")
 		os.Exit(1)
 	}
 
@@ -34,13 +37,6 @@ func main() {
 		os.Exit(1)
 	}
 
-	// Read the API spec file
-	apiSpecContent, err := ioutil.ReadFile(*apiSpecPath)
-	if err != nil {
-		fmt.Printf("Error reading API spec file: %v\n", err)
-		os.Exit(1)
-	}
-
 	// Create a new validator
 	v, err := validator.NewValidator()
 	if err != nil {
@@ -48,12 +44,20 @@ func main() {
 		os.Exit(1)
 	}
 
-	// Validate the API spec
+	// Validate the API spec, passed by path so that references to other
+	// files resolve relative to it
 	params := map[string]interface{}{
-		"api_spec": string(apiSpecContent),
+		"api_spec": *apiSpecPath,
+	}
+	if *ruleNames != "" {
+		var selected []interface{}
+		for _, name := range strings.Split(*ruleNames, ",") {
+			selected = append(selected, strings.TrimSpace(name))
+		}
+		params["rules"] = selected
 	}
 
-	results, err := v.Validate(params)
+	results, err := v.Validate(context.Background(), params)
 	if err != nil {
 		fmt.Printf("Error validating API spec: %v\n", err)
 		os.Exit(1)
@@ -68,14 +72,18 @@ func main() {
 	}
 	fmt.Println(string(resultsJSON))
 
+	// References that couldn't be resolved hide parts of the spec from the rules
+	if problems, ok := results["reference_errors"].([]string); ok && len(problems) > 0 {
+		fmt.Printf("\n%d reference(s) couldn't be resolved.\n", len(problems))
+		os.Exit(1)
+	}
+
 	// Check if any rules failed
-	if ruleResults, ok := results["results"].(map[string]interface{}); ok {
+	if ruleResults, ok := results["results"].(map[string]*rules.Result); ok {
 		failedRules := 0
-		for ruleName, ruleResult := range ruleResults {
-			if result, ok := ruleResult.(map[string]interface{}); ok {
-				if status, ok := result["status"].(string); ok && status == "failed" {
-					failedRules++
-				}
+		for _, result := range ruleResults {
+			if result.Status == rules.StatusFailed {
+				failedRules++
 			}
 		}
 
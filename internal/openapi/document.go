@@ -0,0 +1,317 @@
+package openapi
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Origin describes where a node of a document was originally defined
+type Origin struct {
+	// File is the file the node was defined in, empty for inline content
+	File string `json:"file,omitempty"`
+	// Pointer is the JSON pointer of the node within its file
+	Pointer string `json:"pointer"`
+	// Line and Column are the 1-based position of the node's key within its
+	// file, or of the node itself for the root
+	Line   int `json:"line,omitempty"`
+	Column int `json:"column,omitempty"`
+}
+
+// position is a line and column within a file
+type position struct {
+	line, column int
+}
+
+// String returns the origin as a JSON reference
+func (o Origin) String() string {
+	return o.File + "#" + o.Pointer
+}
+
+// Document is a parsed OpenAPI document with its references resolved
+type Document struct {
+	// Raw is the document as parsed, with $ref nodes intact
+	Raw map[string]interface{}
+	// Spec is the dereferenced document. $ref nodes are replaced by the
+	// nodes they point to, which are shared, so circular references make
+	// the tree cyclic: walk it with Walk or guard against revisits.
+	Spec map[string]interface{}
+	// File is the file the document was loaded from, empty for inline content
+	File string
+	// Version is the detected specification version. Swagger 2.0 and
+	// OpenAPI 3.x documents are normalized into the same model.
+	Version Version
+	// Problems lists the references that couldn't be resolved
+	Problems []string
+	// Digest identifies the normalized content of the document and of the
+	// files it references. Formatting differences such as YAML versus JSON,
+	// key order or whitespace don't change it.
+	Digest string
+
+	// Paths is the typed model of the paths object, sorted by path
+	Paths []*PathItem
+	// Webhooks are the webhooks of OpenAPI 3.1 documents, sorted by name
+	Webhooks []*PathItem
+	// Schemas are the component schemas, or the definitions of Swagger 2.0
+	// documents, by name
+	Schemas map[string]*Schema
+
+	// origins holds the origin of each map node by address. nodes keeps
+	// those nodes reachable: $ref nodes and unused parts of referenced files
+	// aren't part of Spec, and once collected their addresses could be
+	// reused by nodes synthesized later, which would inherit their origin.
+	origins map[uintptr]Origin
+	nodes   []map[string]interface{}
+	// positions holds the position of every node of each file by JSON pointer
+	positions map[string]map[string]position
+}
+
+// Load parses a Swagger 2.0 or OpenAPI 3.x document in YAML or JSON and
+// resolves its references. Relative file references are resolved against the directory
+// of file, or against baseDir for inline content.
+func Load(content []byte, file, baseDir string) (*Document, error) {
+	raw, positions, err := parse(content)
+	if err != nil {
+		return nil, err
+	}
+	rootDigest := contentDigest(raw, content)
+
+	version, err := detectVersion(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	if file != "" {
+		if abs, err := filepath.Abs(file); err == nil {
+			file = abs
+		}
+	}
+
+	doc := &Document{
+		Raw:     raw,
+		File:    file,
+		Version: version,
+		origins: make(map[uintptr]Origin),
+	}
+
+	r := newResolver(doc, baseDir)
+	doc.Spec = r.resolveRoot(raw, positions, file)
+	doc.Problems = r.problems
+	sort.Strings(doc.Problems)
+	doc.Digest = r.digest(rootDigest)
+	doc.positions = r.positions
+	doc.buildModel()
+
+	return doc, nil
+}
+
+// parse unmarshals YAML or JSON content into a normalized map, along with
+// the position of every node by JSON pointer. Errors are left for callers
+// to describe.
+func parse(content []byte) (map[string]interface{}, map[string]position, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(content, &root); err != nil {
+		return nil, nil, err
+	}
+
+	var raw interface{}
+	if err := root.Decode(&raw); err != nil {
+		return nil, nil, err
+	}
+
+	spec, ok := normalize(raw).(map[string]interface{})
+	if !ok {
+		return nil, nil, fmt.Errorf("document is not an object")
+	}
+
+	positions := make(map[string]position)
+	if len(root.Content) > 0 {
+		node := root.Content[0]
+		positions[""] = position{node.Line, node.Column}
+		recordPositions(node, "", positions, 0)
+	}
+	return spec, positions, nil
+}
+
+// maxAliasDepth bounds the nesting of aliases followed when recording positions
+const maxAliasDepth = 16
+
+// recordPositions records the position of the entries of a YAML node by JSON
+// pointer. Map entries are positioned at their key. Aliases and merge keys
+// are followed, so their entries are positioned at the anchored node.
+func recordPositions(node *yaml.Node, pointer string, positions map[string]position, aliases int) {
+	switch node.Kind {
+	case yaml.AliasNode:
+		if aliases < maxAliasDepth && node.Alias != nil {
+			recordPositions(node.Alias, pointer, positions, aliases+1)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if key.Tag == "!!merge" {
+				continue
+			}
+
+			child := pointer + "/" + escapePointerToken(key.Value)
+			positions[child] = position{key.Line, key.Column}
+			recordPositions(value, child, positions, aliases)
+		}
+
+		// Merged entries don't override the mapping's own, and earlier
+		// mappings of a merged sequence take precedence over later ones
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Tag != "!!merge" {
+				continue
+			}
+			sources := []*yaml.Node{node.Content[i+1]}
+			if node.Content[i+1].Kind == yaml.SequenceNode {
+				sources = node.Content[i+1].Content
+			}
+			for _, source := range sources {
+				merged := make(map[string]position)
+				recordPositions(source, pointer, merged, aliases)
+				for k, v := range merged {
+					if _, ok := positions[k]; !ok {
+						positions[k] = v
+					}
+				}
+			}
+		}
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			child := pointer + "/" + strconv.Itoa(i)
+			positions[child] = position{item.Line, item.Column}
+			recordPositions(item, child, positions, aliases)
+		}
+	}
+}
+
+// contentDigest returns the digest of a parsed file. Maps are encoded with
+// sorted keys, so the digest only depends on the content. Content JSON can't
+// represent, such as NaN, falls back to the raw bytes.
+func contentDigest(node interface{}, content []byte) string {
+	encoded, err := json.Marshal(node)
+	if err != nil {
+		encoded = content
+	}
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:])
+}
+
+// normalize converts the maps produced by the YAML decoder to
+// map[string]interface{}. Mappings with non-string keys, such as unquoted
+// response codes, would otherwise be invisible to rules.
+func normalize(node interface{}) interface{} {
+	switch n := node.(type) {
+	case map[string]interface{}:
+		for k, v := range n {
+			n[k] = normalize(v)
+		}
+		return n
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(n))
+		for k, v := range n {
+			m[fmt.Sprint(k)] = normalize(v)
+		}
+		return m
+	case []interface{}:
+		for i, v := range n {
+			n[i] = normalize(v)
+		}
+		return n
+	default:
+		return node
+	}
+}
+
+// Origin returns where a map node of the dereferenced document was
+// originally defined
+func (d *Document) Origin(node map[string]interface{}) (Origin, bool) {
+	origin, ok := d.origins[nodeID(node)]
+	return origin, ok
+}
+
+// setOrigin records where a map node was originally defined
+func (d *Document) setOrigin(node map[string]interface{}, origin Origin) {
+	d.origins[nodeID(node)] = origin
+	d.nodes = append(d.nodes, node)
+}
+
+// Location returns where a map node of the dereferenced document was
+// originally defined, falling back to the root of the document for nodes
+// that were synthesized while loading it
+func (d *Document) Location(node map[string]interface{}) Origin {
+	if origin, ok := d.Origin(node); ok {
+		return origin
+	}
+	origin, _ := d.Origin(d.Spec)
+	return origin
+}
+
+// Locate returns the origin of the node at a JSON pointer within one of the
+// document's files, with its position
+func (d *Document) Locate(file, pointer string) Origin {
+	pos := d.positions[file][pointer]
+	return Origin{File: file, Pointer: pointer, Line: pos.line, Column: pos.column}
+}
+
+// Walk visits every object of the dereferenced document once, along with
+// its origin. Visiting stops below an object when visit returns false.
+func (d *Document) Walk(visit func(node map[string]interface{}, origin Origin) bool) {
+	seen := make(map[uintptr]bool)
+
+	var walk func(node interface{})
+	walk = func(node interface{}) {
+		switch n := node.(type) {
+		case map[string]interface{}:
+			id := nodeID(n)
+			if seen[id] {
+				return
+			}
+			seen[id] = true
+
+			origin, _ := d.Origin(n)
+			if !visit(n, origin) {
+				return
+			}
+
+			keys := make([]string, 0, len(n))
+			for k := range n {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				walk(n[k])
+			}
+		case []interface{}:
+			for _, v := range n {
+				walk(v)
+			}
+		}
+	}
+
+	walk(d.Spec)
+}
+
+// nodeID returns the identity of a map node
+func nodeID(node map[string]interface{}) uintptr {
+	return reflect.ValueOf(node).Pointer()
+}
+
+// escapePointerToken escapes a JSON pointer reference token
+func escapePointerToken(token string) string {
+	return strings.Replace(strings.Replace(token, "~", "~0", -1), "/", "~1", -1)
+}
+
+// unescapePointerToken unescapes a JSON pointer reference token
+func unescapePointerToken(token string) string {
+	return strings.Replace(strings.Replace(token, "~1", "/", -1), "~0", "~", -1)
+}
@@ -0,0 +1,550 @@
+package openapi
+
+import (
+	"sort"
+	"strings"
+)
+
+// Methods lists the HTTP methods an OpenAPI path item can define
+// operations for, in canonical order
+var Methods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// PathItem is an entry of the document's paths object
+type PathItem struct {
+	Path        string
+	Summary     string
+	Description string
+	// Parameters are the path-level parameters shared by all operations
+	Parameters []*Parameter
+	// Operations are the operations of the path in canonical method order
+	Operations []*Operation
+	Node       map[string]interface{}
+}
+
+// Segments returns the non-empty segments of the path
+func (p *PathItem) Segments() []string {
+	var segments []string
+	for _, segment := range strings.Split(p.Path, "/") {
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	return segments
+}
+
+// EndsWithParameter reports whether the last segment of the path is a
+// path parameter, i.e. the path addresses a single resource
+func (p *PathItem) EndsWithParameter() bool {
+	segments := p.Segments()
+	if len(segments) == 0 {
+		return false
+	}
+	return isPathParameter(segments[len(segments)-1])
+}
+
+// IsCollection reports whether the path addresses a collection of resources
+func (p *PathItem) IsCollection() bool {
+	return !p.EndsWithParameter()
+}
+
+// Operation returns the operation for the given HTTP method, if defined
+func (p *PathItem) Operation(method string) *Operation {
+	method = strings.ToLower(method)
+	for _, op := range p.Operations {
+		if op.Method == method {
+			return op
+		}
+	}
+	return nil
+}
+
+// isPathParameter reports whether a path segment is a path parameter
+func isPathParameter(segment string) bool {
+	return strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}")
+}
+
+// Operation is a single HTTP operation on a path
+type Operation struct {
+	// Method is the lowercase HTTP method
+	Method      string
+	Path        string
+	PathItem    *PathItem
+	OperationID string
+	Summary     string
+	Description string
+	Tags        []string
+	Deprecated  bool
+	// Parameters are the path-level parameters merged with the operation's
+	// own, which override them by name and location
+	Parameters  []*Parameter
+	RequestBody *RequestBody
+	// Responses are sorted by status code
+	Responses []*Response
+	Node      map[string]interface{}
+}
+
+// Parameter returns the parameter with the given name and location, if any
+func (o *Operation) Parameter(name, in string) *Parameter {
+	for _, param := range o.Parameters {
+		if param.Name == name && param.In == in {
+			return param
+		}
+	}
+	return nil
+}
+
+// Response returns the response declared for the given status code, if any
+func (o *Operation) Response(code string) *Response {
+	for _, response := range o.Responses {
+		if response.Code == code {
+			return response
+		}
+	}
+	return nil
+}
+
+// SuccessResponse returns the 200 response, or else the first 2xx
+// response, if any
+func (o *Operation) SuccessResponse() *Response {
+	if response := o.Response("200"); response != nil {
+		return response
+	}
+	for _, response := range o.Responses {
+		if response.IsSuccess() {
+			return response
+		}
+	}
+	return nil
+}
+
+// Parameter is an operation or path-level parameter
+type Parameter struct {
+	Name        string
+	In          string
+	Description string
+	Required    bool
+	Deprecated  bool
+	Schema      *Schema
+	Node        map[string]interface{}
+}
+
+// RequestBody is the request body of an operation
+type RequestBody struct {
+	Description string
+	Required    bool
+	// Content is sorted by media type
+	Content []*MediaType
+	Node    map[string]interface{}
+}
+
+// JSONSchema returns the schema of the JSON content of the request body
+func (b *RequestBody) JSONSchema() *Schema {
+	return jsonSchema(b.Content)
+}
+
+// Response is a response declared by an operation
+type Response struct {
+	// Code is the status code, a range such as 4XX, or "default"
+	Code        string
+	Description string
+	// Headers are sorted by name
+	Headers []*Header
+	// Content is sorted by media type
+	Content []*MediaType
+	Node    map[string]interface{}
+}
+
+// IsSuccess reports whether the response is a 2xx response
+func (r *Response) IsSuccess() bool {
+	return strings.HasPrefix(r.Code, "2")
+}
+
+// IsError reports whether the response is a 4xx or 5xx response
+func (r *Response) IsError() bool {
+	return strings.HasPrefix(r.Code, "4") || strings.HasPrefix(r.Code, "5")
+}
+
+// JSONSchema returns the schema of the JSON content of the response
+func (r *Response) JSONSchema() *Schema {
+	return jsonSchema(r.Content)
+}
+
+// Header returns the header with the given name, compared case-insensitively
+func (r *Response) Header(name string) *Header {
+	for _, header := range r.Headers {
+		if strings.EqualFold(header.Name, name) {
+			return header
+		}
+	}
+	return nil
+}
+
+// Header is a response header
+type Header struct {
+	Name        string
+	Description string
+	Required    bool
+	Schema      *Schema
+	Node        map[string]interface{}
+}
+
+// MediaType is the content of a request body or response for one media type
+type MediaType struct {
+	Type   string
+	Schema *Schema
+	Node   map[string]interface{}
+}
+
+// IsJSON reports whether the media type is JSON
+func (m *MediaType) IsJSON() bool {
+	mediaType := strings.ToLower(strings.TrimSpace(strings.SplitN(m.Type, ";", 2)[0]))
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// jsonSchema returns the schema of the first JSON media type with one
+func jsonSchema(content []*MediaType) *Schema {
+	for _, mediaType := range content {
+		if mediaType.IsJSON() && mediaType.Schema != nil {
+			return mediaType.Schema
+		}
+	}
+	return nil
+}
+
+// buildModel builds the typed model of the dereferenced document.
+// Swagger 2.0 body and form parameters become request bodies and response
+// schemas become content, using the consumes and produces media types, so
+// rules see the same model for every version.
+func (d *Document) buildModel() {
+	d.Paths = d.pathItems(d.Spec["paths"])
+	d.Webhooks = nil
+	d.Schemas = make(map[string]*Schema)
+
+	if d.Version == OpenAPI31 {
+		d.Webhooks = d.pathItems(d.Spec["webhooks"])
+	}
+
+	// Component schemas, or the definitions of Swagger 2.0 documents
+	var schemas map[string]interface{}
+	if d.Version == Swagger2 {
+		schemas, _ = d.Spec["definitions"].(map[string]interface{})
+	} else {
+		components, _ := d.Spec["components"].(map[string]interface{})
+		schemas, _ = components["schemas"].(map[string]interface{})
+	}
+	for name, node := range schemas {
+		if schema := d.schema(node); schema != nil {
+			d.Schemas[name] = schema
+		}
+	}
+}
+
+// pathItems builds the path items of a paths or webhooks object sorted by key
+func (d *Document) pathItems(node interface{}) []*PathItem {
+	paths, _ := node.(map[string]interface{})
+
+	var items []*PathItem
+	for _, path := range sortedKeys(paths) {
+		itemNode, ok := paths[path].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		items = append(items, d.buildPathItem(path, itemNode))
+	}
+	return items
+}
+
+// buildPathItem builds a path item and its operations
+func (d *Document) buildPathItem(path string, node map[string]interface{}) *PathItem {
+	pathParams := d.parameters(node["parameters"])
+	item := &PathItem{
+		Path:        path,
+		Summary:     stringValue(node["summary"]),
+		Description: stringValue(node["description"]),
+		Parameters:  pathParams,
+		Node:        node,
+	}
+	if d.Version == Swagger2 {
+		item.Parameters, _ = d.swaggerRequestBody(pathParams, node)
+	}
+
+	for _, method := range Methods {
+		opNode, ok := node[method].(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		op := &Operation{
+			Method:      method,
+			Path:        path,
+			PathItem:    item,
+			OperationID: stringValue(opNode["operationId"]),
+			Summary:     stringValue(opNode["summary"]),
+			Description: stringValue(opNode["description"]),
+			Tags:        stringList(opNode["tags"]),
+			Deprecated:  boolValue(opNode["deprecated"]),
+			Parameters:  mergeParameters(pathParams, d.parameters(opNode["parameters"])),
+			Responses:   d.responses(opNode),
+			Node:        opNode,
+		}
+		if d.Version == Swagger2 {
+			op.Parameters, op.RequestBody = d.swaggerRequestBody(op.Parameters, opNode)
+		} else if bodyNode, ok := opNode["requestBody"].(map[string]interface{}); ok {
+			op.RequestBody = &RequestBody{
+				Description: stringValue(bodyNode["description"]),
+				Required:    boolValue(bodyNode["required"]),
+				Content:     d.content(bodyNode["content"]),
+				Node:        bodyNode,
+			}
+		}
+
+		item.Operations = append(item.Operations, op)
+	}
+
+	return item
+}
+
+// parameters builds a list of parameters
+func (d *Document) parameters(node interface{}) []*Parameter {
+	list, _ := node.([]interface{})
+
+	var params []*Parameter
+	for _, entry := range list {
+		paramNode, ok := entry.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		param := &Parameter{
+			Name:        stringValue(paramNode["name"]),
+			In:          stringValue(paramNode["in"]),
+			Description: stringValue(paramNode["description"]),
+			Required:    boolValue(paramNode["required"]),
+			Deprecated:  boolValue(paramNode["deprecated"]),
+			Schema:      d.schema(paramNode["schema"]),
+			Node:        paramNode,
+		}
+		if d.Version == Swagger2 && param.In != "body" {
+			// Swagger 2.0 declares the type of other parameters inline
+			param.Schema = d.schema(paramNode)
+		}
+		params = append(params, param)
+	}
+	return params
+}
+
+// swaggerRequestBody separates the body and form parameters of a Swagger 2.0
+// operation into a request body and returns the remaining parameters
+func (d *Document) swaggerRequestBody(params []*Parameter, opNode map[string]interface{}) ([]*Parameter, *RequestBody) {
+	var remaining []*Parameter
+	var body *RequestBody
+	properties := make(map[string]interface{})
+	var required []interface{}
+
+	for _, param := range params {
+		switch param.In {
+		case "body":
+			body = &RequestBody{
+				Description: param.Description,
+				Required:    param.Required,
+				Node:        param.Node,
+			}
+			for _, mediaType := range d.mediaTypes(opNode, "consumes") {
+				body.Content = append(body.Content, &MediaType{Type: mediaType, Schema: param.Schema, Node: param.Node})
+			}
+		case "formData":
+			properties[param.Name] = param.Node
+			if param.Required {
+				required = append(required, param.Name)
+			}
+		default:
+			remaining = append(remaining, param)
+		}
+	}
+
+	if body == nil && len(properties) > 0 {
+		formNode := map[string]interface{}{
+			"type":       "object",
+			"properties": properties,
+		}
+		if len(required) > 0 {
+			formNode["required"] = required
+		}
+
+		body = &RequestBody{Required: len(required) > 0, Node: formNode}
+		for _, mediaType := range d.mediaTypes(opNode, "consumes") {
+			if mediaType == "application/x-www-form-urlencoded" || mediaType == "multipart/form-data" {
+				body.Content = append(body.Content, &MediaType{Type: mediaType, Schema: d.schema(formNode), Node: formNode})
+			}
+		}
+		if len(body.Content) == 0 {
+			body.Content = []*MediaType{{Type: "application/x-www-form-urlencoded", Schema: d.schema(formNode), Node: formNode}}
+		}
+	}
+
+	return remaining, body
+}
+
+// mediaTypes returns the Swagger 2.0 consumes or produces media types of an
+// operation, which override the document's, defaulting to application/json
+func (d *Document) mediaTypes(opNode map[string]interface{}, key string) []string {
+	if mediaTypes := stringList(opNode[key]); len(mediaTypes) > 0 {
+		return mediaTypes
+	}
+	if mediaTypes := stringList(d.Spec[key]); len(mediaTypes) > 0 {
+		return mediaTypes
+	}
+	return []string{"application/json"}
+}
+
+// mergeParameters merges path-level parameters with operation parameters,
+// which override them by name and location
+func mergeParameters(pathParams, opParams []*Parameter) []*Parameter {
+	var merged []*Parameter
+	for _, pathParam := range pathParams {
+		overridden := false
+		for _, opParam := range opParams {
+			if opParam.Name == pathParam.Name && opParam.In == pathParam.In {
+				overridden = true
+				break
+			}
+		}
+		if !overridden {
+			merged = append(merged, pathParam)
+		}
+	}
+	return append(merged, opParams...)
+}
+
+// responses builds the responses of an operation sorted by status code
+func (d *Document) responses(opNode map[string]interface{}) []*Response {
+	responsesNode, _ := opNode["responses"].(map[string]interface{})
+
+	var responses []*Response
+	for _, code := range sortedKeys(responsesNode) {
+		responseNode, ok := responsesNode[code].(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		response := &Response{
+			Code:        code,
+			Description: stringValue(responseNode["description"]),
+			Content:     d.content(responseNode["content"]),
+			Node:        responseNode,
+		}
+		if d.Version == Swagger2 {
+			// Swagger 2.0 declares a single schema for the produced media types
+			if schema := d.schema(responseNode["schema"]); schema != nil {
+				for _, mediaType := range d.mediaTypes(opNode, "produces") {
+					response.Content = append(response.Content, &MediaType{Type: mediaType, Schema: schema, Node: responseNode})
+				}
+			}
+		}
+
+		headersNode, _ := responseNode["headers"].(map[string]interface{})
+		for _, name := range sortedKeys(headersNode) {
+			headerNode, ok := headersNode[name].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			header := &Header{
+				Name:        name,
+				Description: stringValue(headerNode["description"]),
+				Required:    boolValue(headerNode["required"]),
+				Schema:      d.schema(headerNode["schema"]),
+				Node:        headerNode,
+			}
+			if d.Version == Swagger2 {
+				// Swagger 2.0 declares the type of headers inline
+				header.Schema = d.schema(headerNode)
+			}
+			response.Headers = append(response.Headers, header)
+		}
+
+		responses = append(responses, response)
+	}
+	return responses
+}
+
+// content builds the media types of a content object sorted by media type
+func (d *Document) content(node interface{}) []*MediaType {
+	contentNode, _ := node.(map[string]interface{})
+
+	var content []*MediaType
+	for _, mediaType := range sortedKeys(contentNode) {
+		mediaNode, ok := contentNode[mediaType].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		content = append(content, &MediaType{
+			Type:   mediaType,
+			Schema: d.schema(mediaNode["schema"]),
+			Node:   mediaNode,
+		})
+	}
+	return content
+}
+
+// Path returns the path item for the given path, if defined
+func (d *Document) Path(path string) *PathItem {
+	for _, item := range d.Paths {
+		if item.Path == path {
+			return item
+		}
+	}
+	return nil
+}
+
+// Operations returns every operation of the document, ordered by path and method
+func (d *Document) Operations() []*Operation {
+	var ops []*Operation
+	for _, item := range d.Paths {
+		ops = append(ops, item.Operations...)
+	}
+	return ops
+}
+
+// SchemaNames returns the names of the component schemas in sorted order
+func (d *Document) SchemaNames() []string {
+	names := make([]string, 0, len(d.Schemas))
+	for name := range d.Schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// sortedKeys returns the keys of a map in sorted order
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// stringValue returns a node as a string, or "" if it isn't one
+func stringValue(node interface{}) string {
+	s, _ := node.(string)
+	return s
+}
+
+// boolValue returns a node as a bool, or false if it isn't one
+func boolValue(node interface{}) bool {
+	b, _ := node.(bool)
+	return b
+}
+
+// stringList returns the strings of a list node
+func stringList(node interface{}) []string {
+	list, _ := node.([]interface{})
+
+	var strs []string
+	for _, entry := range list {
+		if s, ok := entry.(string); ok {
+			strs = append(strs, s)
+		}
+	}
+	return strs
+}
@@ -0,0 +1,311 @@
+package openapi
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxRefChain bounds the number of $ref hops followed for a single reference
+const maxRefChain = 64
+
+// resolver dereferences the $ref nodes of a document and the files it references
+type resolver struct {
+	doc     *Document
+	baseDir string
+	files   map[string]map[string]interface{}
+	// positions holds the position of every node of each file by JSON pointer
+	positions map[string]map[string]position
+	// digests holds the content digest of each referenced file
+	digests  map[string]string
+	visited  map[uintptr]bool
+	problems []string
+}
+
+// newResolver creates a resolver for the given document
+func newResolver(doc *Document, baseDir string) *resolver {
+	return &resolver{
+		doc:       doc,
+		baseDir:   baseDir,
+		files:     make(map[string]map[string]interface{}),
+		positions: make(map[string]map[string]position),
+		digests:   make(map[string]string),
+		visited:   make(map[uintptr]bool),
+	}
+}
+
+// resolveRoot dereferences a copy of the root document
+func (r *resolver) resolveRoot(raw map[string]interface{}, positions map[string]position, file string) map[string]interface{} {
+	root := deepCopy(raw).(map[string]interface{})
+	r.files[file] = root
+	r.positions[file] = positions
+	r.recordOrigins(root, file, "")
+	return r.walk(root, file).(map[string]interface{})
+}
+
+// digest combines the digest of the root document with those of the files
+// it references
+func (r *resolver) digest(rootDigest string) string {
+	files := make([]string, 0, len(r.digests))
+	for file := range r.digests {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	h := sha256.New()
+	h.Write([]byte(rootDigest))
+	for _, file := range files {
+		fmt.Fprintf(h, "\x00%s\x00%s", file, r.digests[file])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// dir returns the directory that references made from file are relative to
+func (r *resolver) dir(file string) string {
+	if file == "" {
+		return r.baseDir
+	}
+	return filepath.Dir(file)
+}
+
+// load returns the root of a referenced file, parsing it on first use
+func (r *resolver) load(file string) (map[string]interface{}, error) {
+	if root, ok := r.files[file]; ok {
+		return root, nil
+	}
+
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("error reading referenced file: %v", err)
+	}
+
+	root, positions, err := parse(content)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+	r.digests[file] = contentDigest(root, content)
+
+	r.files[file] = root
+	r.positions[file] = positions
+	r.recordOrigins(root, file, "")
+	return root, nil
+}
+
+// recordOrigins records the file, JSON pointer and position of every object
+// of a file
+func (r *resolver) recordOrigins(node interface{}, file, pointer string) {
+	switch n := node.(type) {
+	case map[string]interface{}:
+		pos := r.positions[file][pointer]
+		r.doc.setOrigin(n, Origin{File: file, Pointer: pointer, Line: pos.line, Column: pos.column})
+		for k, v := range n {
+			r.recordOrigins(v, file, pointer+"/"+escapePointerToken(k))
+		}
+	case []interface{}:
+		for i, v := range n {
+			r.recordOrigins(v, file, pointer+"/"+strconv.Itoa(i))
+		}
+	}
+}
+
+// walk dereferences a node in place and returns its replacement. Every
+// object is walked once, which keeps circular references finite.
+func (r *resolver) walk(node interface{}, file string) interface{} {
+	switch n := node.(type) {
+	case map[string]interface{}:
+		if ref, ok := n["$ref"].(string); ok {
+			target, targetFile, err := r.follow(ref, file)
+			if err != nil {
+				r.problems = append(r.problems, fmt.Sprintf("%s: %v", r.originOf(n), err))
+				return n
+			}
+			resolved := r.walk(target, targetFile)
+			if r.doc.Version == OpenAPI31 {
+				return r.mergeSiblings(n, resolved, file)
+			}
+			return resolved
+		}
+
+		id := nodeID(n)
+		if r.visited[id] {
+			return n
+		}
+		r.visited[id] = true
+
+		for k, v := range n {
+			n[k] = r.walk(v, file)
+		}
+		return n
+	case []interface{}:
+		for i, v := range n {
+			n[i] = r.walk(v, file)
+		}
+		return n
+	default:
+		return node
+	}
+}
+
+// mergeSiblings applies the keywords next to a $ref to the node it resolved
+// to, as OpenAPI 3.1 allows. Siblings are ignored by earlier versions. The
+// target is shared, so siblings are applied to a copy of it that keeps its
+// origin; sibling keywords override those of the target.
+func (r *resolver) mergeSiblings(ref map[string]interface{}, resolved interface{}, file string) interface{} {
+	target, ok := resolved.(map[string]interface{})
+	if !ok || len(ref) == 1 {
+		return resolved
+	}
+
+	merged := make(map[string]interface{}, len(target)+len(ref)-1)
+	for k, v := range target {
+		merged[k] = v
+	}
+	for k, v := range ref {
+		if k != "$ref" {
+			merged[k] = r.walk(v, file)
+		}
+	}
+
+	if origin, ok := r.doc.Origin(target); ok {
+		r.doc.setOrigin(merged, origin)
+	}
+	r.visited[nodeID(merged)] = true
+	return merged
+}
+
+// follow resolves a reference made from file to the node it points to,
+// following chains of references, and returns the file that node is in
+func (r *resolver) follow(ref, file string) (interface{}, string, error) {
+	for i := 0; i < maxRefChain; i++ {
+		target, targetFile, err := r.lookup(ref, file)
+		if err != nil {
+			return nil, "", err
+		}
+
+		next, ok := target.(map[string]interface{})
+		if !ok {
+			return target, targetFile, nil
+		}
+		nextRef, ok := next["$ref"].(string)
+		if !ok {
+			return target, targetFile, nil
+		}
+
+		ref, file = nextRef, targetFile
+	}
+
+	return nil, "", fmt.Errorf("circular reference: %s", ref)
+}
+
+// lookup returns the node a single reference made from file points to
+func (r *resolver) lookup(ref, file string) (interface{}, string, error) {
+	location, fragment := ref, ""
+	if i := strings.Index(ref, "#"); i >= 0 {
+		location, fragment = ref[:i], ref[i+1:]
+	}
+
+	if strings.Contains(location, "://") {
+		return nil, "", fmt.Errorf("remote references are not supported: %s", ref)
+	}
+
+	targetFile := file
+	if filepath.IsAbs(location) {
+		targetFile = filepath.Clean(location)
+	} else if location != "" {
+		targetFile = filepath.Clean(filepath.Join(r.dir(file), location))
+	}
+
+	root, err := r.load(targetFile)
+	if err != nil {
+		return nil, "", err
+	}
+
+	node, targetFile, err := r.resolvePointer(root, fragment, targetFile)
+	if err != nil {
+		return nil, "", fmt.Errorf("unresolved reference %s: %v", ref, err)
+	}
+	return node, targetFile, nil
+}
+
+// originOf describes the origin of a node for problem reports
+func (r *resolver) originOf(node map[string]interface{}) string {
+	if origin, ok := r.doc.Origin(node); ok {
+		return origin.String()
+	}
+	return "#"
+}
+
+// resolvePointer returns the node a JSON pointer fragment points to within
+// the root of file. References met along the way are followed, so the node
+// may live in another file, which is returned as well.
+func (r *resolver) resolvePointer(root interface{}, fragment, file string) (interface{}, string, error) {
+	if decoded, err := url.PathUnescape(fragment); err == nil {
+		fragment = decoded
+	}
+	if fragment == "" {
+		return root, file, nil
+	}
+	if !strings.HasPrefix(fragment, "/") {
+		return nil, "", fmt.Errorf("invalid JSON pointer: %s", fragment)
+	}
+
+	node := root
+	for _, token := range strings.Split(fragment[1:], "/") {
+		token = unescapePointerToken(token)
+
+		if m, ok := node.(map[string]interface{}); ok {
+			if ref, ok := m["$ref"].(string); ok {
+				target, targetFile, err := r.follow(ref, file)
+				if err != nil {
+					return nil, "", err
+				}
+				node, file = target, targetFile
+			}
+		}
+
+		switch n := node.(type) {
+		case map[string]interface{}:
+			next, ok := n[token]
+			if !ok {
+				return nil, "", fmt.Errorf("%s not found", token)
+			}
+			node = next
+		case []interface{}:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(n) {
+				return nil, "", fmt.Errorf("invalid array index: %s", token)
+			}
+			node = n[i]
+		default:
+			return nil, "", fmt.Errorf("%s not found", token)
+		}
+	}
+
+	return node, file, nil
+}
+
+// deepCopy copies a parsed document so it can be dereferenced in place
+func deepCopy(node interface{}) interface{} {
+	switch n := node.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(n))
+		for k, v := range n {
+			m[k] = deepCopy(v)
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(n))
+		for i, v := range n {
+			s[i] = deepCopy(v)
+		}
+		return s
+	default:
+		return node
+	}
+}
@@ -0,0 +1,267 @@
+package openapi
+
+import (
+	"strings"
+)
+
+// Schema is a schema object of the dereferenced document. Schemas may be
+// recursive, so their subschemas are built on access rather than up front.
+type Schema struct {
+	Node map[string]interface{}
+
+	doc *Document
+}
+
+// schema wraps a schema node, returning nil if it isn't an object
+func (d *Document) schema(node interface{}) *Schema {
+	m, ok := node.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	return &Schema{Node: m, doc: d}
+}
+
+// Name returns the name of the component schema the schema was defined
+// as, or "" for inline schemas
+func (s *Schema) Name() string {
+	origin, ok := s.doc.Origin(s.Node)
+	if !ok {
+		return ""
+	}
+
+	for _, prefix := range []string{"/components/schemas/", "/definitions/"} {
+		if !strings.HasPrefix(origin.Pointer, prefix) {
+			continue
+		}
+		name := origin.Pointer[len(prefix):]
+		if strings.Contains(name, "/") {
+			return ""
+		}
+		return unescapePointerToken(name)
+	}
+	return ""
+}
+
+// Origin returns where the schema was originally defined
+func (s *Schema) Origin() Origin {
+	origin, _ := s.doc.Origin(s.Node)
+	return origin
+}
+
+// Types returns the declared types of the schema. OpenAPI 3.1 schemas may
+// declare several, including "null".
+func (s *Schema) Types() []string {
+	if t, ok := s.Node["type"].(string); ok {
+		return []string{t}
+	}
+	return stringList(s.Node["type"])
+}
+
+// Type returns the declared type of the schema, ignoring "null", or "" if
+// it has none
+func (s *Schema) Type() string {
+	for _, t := range s.Types() {
+		if t != "null" {
+			return t
+		}
+	}
+	return ""
+}
+
+// Format returns the declared format of the schema
+func (s *Schema) Format() string {
+	return stringValue(s.Node["format"])
+}
+
+// Nullable reports whether the schema accepts null, with nullable in
+// OpenAPI 3.0, x-nullable in Swagger 2.0 or a "null" type in OpenAPI 3.1
+func (s *Schema) Nullable() bool {
+	if boolValue(s.Node["nullable"]) || boolValue(s.Node["x-nullable"]) {
+		return true
+	}
+	for _, t := range s.Types() {
+		if t == "null" {
+			return true
+		}
+	}
+	return false
+}
+
+// Required returns the names of the schema's required properties
+func (s *Schema) Required() []string {
+	return stringList(s.Node["required"])
+}
+
+// IsRequired reports whether the schema requires the named property
+func (s *Schema) IsRequired(name string) bool {
+	for _, required := range s.Required() {
+		if required == name {
+			return true
+		}
+	}
+	return false
+}
+
+// PropertyNames returns the names of the schema's own properties in sorted order
+func (s *Schema) PropertyNames() []string {
+	properties, _ := s.Node["properties"].(map[string]interface{})
+	return sortedKeys(properties)
+}
+
+// Property returns the schema of the named property, if declared
+func (s *Schema) Property(name string) *Schema {
+	properties, _ := s.Node["properties"].(map[string]interface{})
+	return s.doc.schema(properties[name])
+}
+
+// Items returns the schema of the items of an array schema
+func (s *Schema) Items() *Schema {
+	return s.doc.schema(s.Node["items"])
+}
+
+// AllOf returns the subschemas of the schema's allOf
+func (s *Schema) AllOf() []*Schema {
+	return s.subschemas("allOf")
+}
+
+// OneOf returns the subschemas of the schema's oneOf
+func (s *Schema) OneOf() []*Schema {
+	return s.subschemas("oneOf")
+}
+
+// AnyOf returns the subschemas of the schema's anyOf
+func (s *Schema) AnyOf() []*Schema {
+	return s.subschemas("anyOf")
+}
+
+// subschemas returns the schemas of a list keyword
+func (s *Schema) subschemas(keyword string) []*Schema {
+	list, _ := s.Node[keyword].([]interface{})
+
+	var schemas []*Schema
+	for _, entry := range list {
+		if schema := s.doc.schema(entry); schema != nil {
+			schemas = append(schemas, schema)
+		}
+	}
+	return schemas
+}
+
+// Enum returns the allowed values of the schema
+func (s *Schema) Enum() []interface{} {
+	enum, _ := s.Node["enum"].([]interface{})
+	return enum
+}
+
+// FindProperty returns the schema of the named property, declared by the
+// schema itself or by one of its allOf subschemas
+func (s *Schema) FindProperty(name string) *Schema {
+	var found *Schema
+	s.composed(func(schema *Schema) bool {
+		found = schema.Property(name)
+		return found == nil
+	})
+	return found
+}
+
+// RequiresProperty reports whether the schema or one of its allOf
+// subschemas requires the named property
+func (s *Schema) RequiresProperty(name string) bool {
+	required := false
+	s.composed(func(schema *Schema) bool {
+		required = schema.IsRequired(name)
+		return !required
+	})
+	return required
+}
+
+// AllPropertyNames returns the names of the properties declared by the
+// schema and its allOf subschemas in sorted order
+func (s *Schema) AllPropertyNames() []string {
+	names := make(map[string]interface{})
+	s.composed(func(schema *Schema) bool {
+		for _, name := range schema.PropertyNames() {
+			names[name] = true
+		}
+		return true
+	})
+	return sortedKeys(names)
+}
+
+// ComposedType returns the declared type of the schema, or else the first
+// type declared by one of its allOf subschemas
+func (s *Schema) ComposedType() string {
+	t := ""
+	s.composed(func(schema *Schema) bool {
+		t = schema.Type()
+		return t == ""
+	})
+	return t
+}
+
+// AcceptedType returns the declared type of the schema, or else the first
+// type other than null declared by its allOf subschemas or by the
+// alternatives of its oneOf and anyOf subschemas
+func (s *Schema) AcceptedType() string {
+	t := ""
+	s.variants(func(schema *Schema) bool {
+		t = schema.Type()
+		return t == ""
+	})
+	return t
+}
+
+// AcceptsNull reports whether the schema accepts null, as declared by the
+// schema itself, by one of its allOf subschemas, as in the OpenAPI 3.0
+// idiom of a nullable allOf wrapping a $ref, or by one of the alternatives
+// of its oneOf and anyOf subschemas
+func (s *Schema) AcceptsNull() bool {
+	nullable := false
+	s.variants(func(schema *Schema) bool {
+		nullable = schema.Nullable()
+		return !nullable
+	})
+	return nullable
+}
+
+// composed visits the schema and then its allOf subschemas, depth first,
+// until visit returns false
+func (s *Schema) composed(visit func(schema *Schema) bool) {
+	s.walkSubschemas([]string{"allOf"}, visit)
+}
+
+// variants visits the schema and then its allOf, oneOf and anyOf
+// subschemas, depth first, until visit returns false
+func (s *Schema) variants(visit func(schema *Schema) bool) {
+	s.walkSubschemas([]string{"allOf", "oneOf", "anyOf"}, visit)
+}
+
+// walkSubschemas visits the schema and then the subschemas of the given
+// keywords, depth first, until visit returns false. Each schema is visited
+// once, so recursive compositions terminate.
+func (s *Schema) walkSubschemas(keywords []string, visit func(schema *Schema) bool) {
+	seen := make(map[uintptr]bool)
+
+	var walk func(schema *Schema) bool
+	walk = func(schema *Schema) bool {
+		id := nodeID(schema.Node)
+		if seen[id] {
+			return true
+		}
+		seen[id] = true
+
+		if !visit(schema) {
+			return false
+		}
+		for _, keyword := range keywords {
+			for _, sub := range schema.subschemas(keyword) {
+				if !walk(sub) {
+					return false
+				}
+			}
+		}
+		return true
+	}
+
+	walk(s)
+}
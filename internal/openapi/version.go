@@ -0,0 +1,54 @@
+package openapi
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is the specification version of a document
+type Version string
+
+const (
+	// Swagger2 is Swagger 2.0
+	Swagger2 Version = "2.0"
+	// OpenAPI30 is OpenAPI 3.0.x
+	OpenAPI30 Version = "3.0"
+	// OpenAPI31 is OpenAPI 3.1.x
+	OpenAPI31 Version = "3.1"
+)
+
+// detectVersion detects the specification version from the swagger or
+// openapi field. Documents declaring neither are treated as OpenAPI 3.0.
+func detectVersion(raw map[string]interface{}) (Version, error) {
+	if swagger, ok := raw["swagger"]; ok {
+		if versionString(swagger) != "2.0" {
+			return "", fmt.Errorf("unsupported Swagger version: %v", swagger)
+		}
+		return Swagger2, nil
+	}
+
+	declared, ok := raw["openapi"]
+	if !ok {
+		return OpenAPI30, nil
+	}
+
+	version := versionString(declared)
+	switch {
+	case version == "3.0" || strings.HasPrefix(version, "3.0."):
+		return OpenAPI30, nil
+	case version == "3.1" || strings.HasPrefix(version, "3.1."):
+		return OpenAPI31, nil
+	default:
+		return "", fmt.Errorf("unsupported OpenAPI version: %s", version)
+	}
+}
+
+// versionString returns a declared version as a string. Unquoted versions
+// such as 3.0 are decoded as numbers and keep their decimal.
+func versionString(declared interface{}) string {
+	if v, ok := declared.(float64); ok {
+		return strconv.FormatFloat(v, 'f', 1, 64)
+	}
+	return fmt.Sprint(declared)
+}
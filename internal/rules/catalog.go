@@ -0,0 +1,225 @@
+package rules
+
+import (
+	"fmt"
+	"strings"
+)
+
+// adrDocumented is implemented by rules that carry a hand-written ADR summary
+type adrDocumented interface {
+	ADR() string
+}
+
+// graded is implemented by built-in rules whose issues are less severe
+// than the default severity
+type graded interface {
+	Severity() Severity
+}
+
+// described is implemented by built-in rules that carry metadata
+type described interface {
+	Metadata() Metadata
+}
+
+// DefaultCategory is the category of rules with neither a category nor tags
+const DefaultCategory = "general"
+
+// Metadata describes the ADR behind a rule and how the rule is classified
+type Metadata struct {
+	// ADRID and ADRLink identify the ADR the rule enforces
+	ADRID   string `json:"adr_id,omitempty"`
+	ADRLink string `json:"adr_link,omitempty"`
+	// Category groups the findings of the rule in reports. It defaults to
+	// the first tag.
+	Category     string   `json:"category,omitempty"`
+	Tags         []string `json:"tags,omitempty"`
+	Owner        string   `json:"owner,omitempty"`
+	IntroducedIn string   `json:"introduced_in,omitempty"`
+	Rationale    string   `json:"rationale,omitempty"`
+}
+
+// RuleMetadata returns the metadata of a rule, with its category resolved
+func RuleMetadata(rule Rule) Metadata {
+	var metadata Metadata
+	switch r := rule.(type) {
+	case *JSONRule:
+		metadata = r.Metadata
+	case described:
+		metadata = r.Metadata()
+	}
+
+	if metadata.Tags == nil {
+		metadata.Tags = []string{}
+	}
+	if metadata.Category == "" {
+		metadata.Category = DefaultCategory
+		if len(metadata.Tags) > 0 {
+			metadata.Category = metadata.Tags[0]
+		}
+	}
+	return metadata
+}
+
+// Describe returns the catalog entry for a rule: its name, description,
+// severity, metadata, conditions and ADR text, and for JSON rules the file
+// they were loaded from
+func Describe(rule Rule) map[string]interface{} {
+	metadata := RuleMetadata(rule)
+	entry := map[string]interface{}{
+		"name":        rule.Name(),
+		"description": rule.Description(),
+		"severity":    DefaultSeverity,
+		"enabled":     true,
+		"conditions":  []Condition{},
+		"adr":         ADRText(rule),
+		"category":    metadata.Category,
+		"tags":        metadata.Tags,
+	}
+	for key, value := range map[string]string{
+		"adr_id":        metadata.ADRID,
+		"adr_link":      metadata.ADRLink,
+		"owner":         metadata.Owner,
+		"introduced_in": metadata.IntroducedIn,
+		"rationale":     metadata.Rationale,
+	} {
+		if value != "" {
+			entry[key] = value
+		}
+	}
+
+	if gradedRule, ok := rule.(graded); ok {
+		entry["severity"] = gradedRule.Severity()
+	}
+	if jsonRule, ok := rule.(*JSONRule); ok {
+		entry["severity"] = jsonRule.Severity
+		entry["enabled"] = jsonRule.Enabled
+		entry["conditions"] = jsonRule.Conditions
+		entry["source"] = jsonRule.FilePath
+	}
+
+	return entry
+}
+
+// builtinVersion is the version of the built-in rules, which only change
+// with the server itself
+const builtinVersion = "builtin"
+
+// Version identifies the definition of a rule. JSON rules are versioned by
+// the content of their file, so editing the file changes the version.
+func Version(rule Rule) string {
+	if jsonRule, ok := rule.(*JSONRule); ok {
+		return jsonRule.Version
+	}
+	return builtinVersion
+}
+
+// Tags returns the tags of a rule, such as naming or pagination
+func Tags(rule Rule) []string {
+	return RuleMetadata(rule).Tags
+}
+
+// HasTag reports whether a rule has the given tag
+func HasTag(rule Rule, tag string) bool {
+	for _, t := range Tags(rule) {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// HasAnyTag reports whether a rule has any of the given tags
+func HasAnyTag(rule Rule, tags []string) bool {
+	for _, tag := range tags {
+		if HasTag(rule, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// ADRText returns the ADR text behind a rule. For JSON rules the condition
+// messages are the ADR statements, so they are listed in order.
+func ADRText(rule Rule) string {
+	if documented, ok := rule.(adrDocumented); ok {
+		return documented.ADR()
+	}
+
+	jsonRule, ok := rule.(*JSONRule)
+	if !ok {
+		return rule.Description()
+	}
+
+	var b strings.Builder
+	for _, condition := range jsonRule.Conditions {
+		fmt.Fprintf(&b, "- %s\n", condition.Message)
+	}
+	return b.String()
+}
+
+// Markdown renders a rule's catalog entry as a markdown document
+func Markdown(rule Rule) string {
+	entry := Describe(rule)
+	metadata := RuleMetadata(rule)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", rule.Name())
+	fmt.Fprintf(&b, "%s\n\n", rule.Description())
+	fmt.Fprintf(&b, "- Severity: %s\n", entry["severity"])
+	fmt.Fprintf(&b, "- Enabled: %v\n", entry["enabled"])
+	fmt.Fprintf(&b, "- Category: %s\n", metadata.Category)
+	if len(metadata.Tags) > 0 {
+		fmt.Fprintf(&b, "- Tags: %s\n", strings.Join(metadata.Tags, ", "))
+	}
+	switch {
+	case metadata.ADRID != "" && metadata.ADRLink != "":
+		fmt.Fprintf(&b, "- ADR: [%s](%s)\n", metadata.ADRID, metadata.ADRLink)
+	case metadata.ADRID != "":
+		fmt.Fprintf(&b, "- ADR: %s\n", metadata.ADRID)
+	case metadata.ADRLink != "":
+		fmt.Fprintf(&b, "- ADR: %s\n", metadata.ADRLink)
+	}
+	if metadata.Owner != "" {
+		fmt.Fprintf(&b, "- Owner: %s\n", metadata.Owner)
+	}
+	if metadata.IntroducedIn != "" {
+		fmt.Fprintf(&b, "- Introduced in: %s\n", metadata.IntroducedIn)
+	}
+	b.WriteString("\n")
+
+	b.WriteString("## ADR\n\n")
+	b.WriteString(strings.TrimSpace(ADRText(rule)))
+	b.WriteString("\n")
+
+	if metadata.Rationale != "" {
+		b.WriteString("\n## Rationale\n\n")
+		b.WriteString(strings.TrimSpace(metadata.Rationale))
+		b.WriteString("\n")
+	}
+
+	conditions, _ := entry["conditions"].([]Condition)
+	if len(conditions) > 0 {
+		b.WriteString("\n## Conditions\n\n")
+		for i, condition := range conditions {
+			fmt.Fprintf(&b, "%d. `%s` (%s)", i+1, condition.Type, condition.Severity)
+			if condition.Pattern != "" {
+				fmt.Fprintf(&b, " pattern `%s`", condition.Pattern)
+			}
+			if condition.Path != "" {
+				fmt.Fprintf(&b, " path `%s`", condition.Path)
+			}
+			if condition.Method != "" {
+				fmt.Fprintf(&b, " method `%s`", condition.Method)
+			}
+			if condition.Field != "" {
+				fmt.Fprintf(&b, " field `%s`", condition.Field)
+			}
+			if condition.Format != "" {
+				fmt.Fprintf(&b, " format `%s`", condition.Format)
+			}
+			fmt.Fprintf(&b, ": %s\n", condition.Message)
+		}
+	}
+
+	return b.String()
+}
@@ -0,0 +1,56 @@
+package rules
+
+import (
+	"strings"
+
+	"github.com/solacedev/restv2-api-server-go/internal/openapi"
+)
+
+// singularUserSegments are the path segments addressing the currently logged
+// in user, as in /me or /user
+var singularUserSegments = map[string]bool{"me": true, "user": true}
+
+// returnsCollection reports whether an operation returns a collection of
+// resources: a GET on a collection path
+func returnsCollection(op *openapi.Operation) bool {
+	return op.Method == "get" && isCollection(op.PathItem)
+}
+
+// isCollection reports whether a path addresses a collection of resources.
+// Paths ending in a parameter address single resources, as do the current
+// user and its sub-resources, such as /me/preferences, unless their GET
+// returns a list. Sub-resources of a single resource, such as
+// /environments/{environmentId}/services, are collections unless their GET
+// returns a single object.
+func isCollection(pathItem *openapi.PathItem) bool {
+	if !pathItem.IsCollection() {
+		return false
+	}
+	segments := pathItem.Segments()
+	if len(segments) == 0 {
+		return true
+	}
+	if singularUserSegments[segments[len(segments)-1]] {
+		return false
+	}
+	if len(segments) < 2 {
+		return true
+	}
+
+	parent := segments[len(segments)-2]
+	singularUser := singularUserSegments[parent]
+	if !singularUser && !(strings.HasPrefix(parent, "{") && strings.HasSuffix(parent, "}")) {
+		return true
+	}
+	if op := pathItem.Operation("get"); op != nil {
+		if schema := successSchema(op); schema != nil {
+			if data := schema.FindProperty("data"); data != nil {
+				return data.ComposedType() == "array"
+			}
+			if schema.ComposedType() == "array" {
+				return true
+			}
+		}
+	}
+	return !singularUser
+}
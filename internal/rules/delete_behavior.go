@@ -0,0 +1,110 @@
+package rules
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/solacedev/restv2-api-server-go/internal/openapi"
+)
+
+// DeleteBehaviorRule implements the Solace delete behavior rule
+type DeleteBehaviorRule struct{}
+
+// NewDeleteBehaviorRule creates a new DeleteBehaviorRule instance
+func NewDeleteBehaviorRule() *DeleteBehaviorRule {
+	return &DeleteBehaviorRule{}
+}
+
+// Apply checks that every DELETE operation deletes a single resource,
+// returning 204 No Content on success and 404 Not Found for missing resources
+func (r *DeleteBehaviorRule) Apply(ctx context.Context, doc *openapi.Document) (*Result, error) {
+	var issues []Issue
+
+	// Check if the spec is valid
+	if doc == nil || doc.Spec == nil {
+		return ErrorResult("invalid API spec"), nil
+	}
+
+	// Check if the spec has paths
+	if _, ok := doc.Spec["paths"].(map[string]interface{}); !ok {
+		return ErrorResult("API spec does not have paths"), nil
+	}
+
+	for _, pathItem := range doc.Paths {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		op := pathItem.Operation("delete")
+		if op == nil {
+			continue
+		}
+
+		if isCollection(pathItem) {
+			issues = append(issues, r.issue(doc, op, op.Node,
+				"DELETE operations MUST address a single resource, not a collection",
+				fmt.Sprintf("Move the DELETE operation to the resource path %s/{id}", strings.TrimSuffix(op.Path, "/"))))
+		}
+
+		if response := op.Response("204"); response == nil {
+			issues = append(issues, r.issue(doc, op, op.Node,
+				"DELETE operations MUST return 204 No Content for successful deletions",
+				"Declare a 204 response"))
+		} else if len(response.Content) > 0 {
+			issues = append(issues, r.issue(doc, op, response.Node,
+				"DELETE operations MUST NOT return a body with 204 No Content",
+				"Remove the content of the 204 response"))
+		}
+
+		if response := op.Response("200"); response != nil {
+			issues = append(issues, r.issue(doc, op, response.Node,
+				"DELETE operations MUST NOT return 200 OK",
+				"Return 204 No Content for successful deletions instead"))
+		}
+
+		if op.Response("404") == nil {
+			issues = append(issues, r.issue(doc, op, op.Node,
+				"DELETE operations on non-existent resources MUST return 404 Not Found",
+				"Declare a 404 response"))
+		}
+	}
+
+	return NewResult(issues), nil
+}
+
+// issue returns an issue about a DELETE operation
+func (r *DeleteBehaviorRule) issue(doc *openapi.Document, op *openapi.Operation, node map[string]interface{}, message, suggestion string) Issue {
+	return Issue{
+		Rule:       r.Name(),
+		Severity:   SeverityFromMessage(message),
+		Message:    message,
+		Location:   doc.Location(node),
+		Suggestion: suggestion,
+		Path:       op.Path,
+		Method:     op.Method,
+	}
+}
+
+// Name returns the name of the rule
+func (r *DeleteBehaviorRule) Name() string {
+	return "delete_behavior"
+}
+
+// Description returns the description of the rule
+func (r *DeleteBehaviorRule) Description() string {
+	return "Validates that API delete behavior follows Solace REST API conventions as per ADR"
+}
+
+// Metadata returns the metadata of the rule
+func (r *DeleteBehaviorRule) Metadata() Metadata {
+	return Metadata{Tags: []string{"methods"}}
+}
+
+// ADR returns the convention enforced by the rule
+func (r *DeleteBehaviorRule) ADR() string {
+	return "- DELETE operations MUST return 204 No Content for successful deletions\n" +
+		"- DELETE operations MUST NOT return 200 OK, nor a body with 204 No Content\n" +
+		"- DELETE operations on non-existent or already deleted resources MUST return 404 Not Found\n" +
+		"- DELETE operations MUST address a single resource, not a collection\n"
+}
@@ -0,0 +1,184 @@
+package rules
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/solacedev/restv2-api-server-go/internal/openapi"
+)
+
+// ErrorResponsesRule implements the Solace error responses rule
+type ErrorResponsesRule struct{}
+
+// NewErrorResponsesRule creates a new ErrorResponsesRule instance
+func NewErrorResponsesRule() *ErrorResponsesRule {
+	return &ErrorResponsesRule{}
+}
+
+// Apply checks that every 4xx and 5xx response returns a shared error
+// schema with the standard error fields
+func (r *ErrorResponsesRule) Apply(ctx context.Context, doc *openapi.Document) (*Result, error) {
+	var issues []Issue
+
+	// Check if the spec is valid
+	if doc == nil || doc.Spec == nil {
+		return ErrorResult("invalid API spec"), nil
+	}
+
+	// Check if the spec has paths
+	if _, ok := doc.Spec["paths"].(map[string]interface{}); !ok {
+		return ErrorResult("API spec does not have paths"), nil
+	}
+
+	// Error schemas are shared, so each is checked once
+	checked := make(map[openapi.Origin]bool)
+
+	for _, op := range doc.Operations() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		for _, response := range op.Responses {
+			if !response.IsError() {
+				continue
+			}
+
+			schema := response.JSONSchema()
+			if schema == nil {
+				issue := r.issue(doc, response.Node,
+					fmt.Sprintf("The %s response must return a JSON body with the error schema", response.Code),
+					"Reference the shared error schema from the JSON content of the response")
+				issue.Path, issue.Method = op.Path, op.Method
+				issues = append(issues, issue)
+				continue
+			}
+
+			if !isShared(doc, schema) {
+				issue := r.issue(doc, schema.Node,
+					fmt.Sprintf("The %s response must reference the shared error schema instead of defining its own", response.Code),
+					"Replace the inline schema with a $ref to the shared error schema in components")
+				issue.Path, issue.Method = op.Path, op.Method
+				issues = append(issues, issue)
+			}
+
+			origin := schema.Origin()
+			if checked[origin] {
+				continue
+			}
+			checked[origin] = true
+
+			for _, issue := range r.checkSchema(doc, schema) {
+				if !isShared(doc, schema) {
+					issue.Path, issue.Method = op.Path, op.Method
+				}
+				issues = append(issues, issue)
+			}
+		}
+	}
+
+	return NewResult(issues), nil
+}
+
+// checkSchema checks that an error schema declares and requires the
+// standard error fields
+func (r *ErrorResponsesRule) checkSchema(doc *openapi.Document, schema *openapi.Schema) []Issue {
+	var issues []Issue
+
+	// A format is only checked when the schema declares one, since plain
+	// strings are common for IDs
+	fields := []struct {
+		name, kind, format, description string
+	}{
+		{"message", "string", "", "a user-friendly message detailing what went wrong"},
+		{"errorId", "string", "uuid", "a UUID that was also logged"},
+		{"meta", "object", "", "metadata about the error"},
+		{"validationDetails", "", "", "details about the fields that failed validation"},
+	}
+
+	for _, f := range fields {
+		field := schema.FindProperty(f.name)
+		var issue *Issue
+		switch {
+		case field == nil:
+			issue = r.fieldIssue(doc, schema, schema.Node, f.name,
+				fmt.Sprintf("Errors must include the %s field, %s", f.name, f.description),
+				fmt.Sprintf("Add a %s property to the error schema", f.name))
+		case f.kind != "" && field.ComposedType() != f.kind:
+			issue = r.fieldIssue(doc, schema, field.Node, f.name,
+				fmt.Sprintf("The %s field of errors must be of type %s", f.name, f.kind),
+				fmt.Sprintf("Declare %s with type %s", f.name, f.kind))
+		case f.format != "" && field.Format() != "" && field.Format() != f.format:
+			issue = r.fieldIssue(doc, schema, field.Node, f.name,
+				fmt.Sprintf("The %s field of errors must have format %s", f.name, f.format),
+				fmt.Sprintf("Declare %s with format %s", f.name, f.format))
+		case !schema.RequiresProperty(f.name):
+			issue = r.fieldIssue(doc, schema, schema.Node, f.name,
+				fmt.Sprintf("Errors must always include the %s field", f.name),
+				fmt.Sprintf("Add %s to the required properties of the error schema", f.name))
+		}
+		if issue != nil {
+			issues = append(issues, *issue)
+		}
+	}
+
+	return issues
+}
+
+// issue returns an issue of the rule reported at the given node
+func (r *ErrorResponsesRule) issue(doc *openapi.Document, node map[string]interface{}, message, suggestion string) Issue {
+	return Issue{
+		Rule:       r.Name(),
+		Severity:   SeverityFromMessage(message),
+		Message:    message,
+		Location:   doc.Location(node),
+		Suggestion: suggestion,
+	}
+}
+
+// fieldIssue returns an issue about a field of an error schema
+func (r *ErrorResponsesRule) fieldIssue(doc *openapi.Document, schema *openapi.Schema, node map[string]interface{}, field, message, suggestion string) *Issue {
+	issue := r.issue(doc, node, message, suggestion)
+	issue.Schema = schema.Name()
+	issue.Field = field
+	return &issue
+}
+
+// isShared reports whether a schema is defined outside of the paths of the
+// document, such as in its components or another file, so that operations
+// can share it
+func isShared(doc *openapi.Document, schema *openapi.Schema) bool {
+	origin := schema.Origin()
+	if origin.File != doc.File {
+		return true
+	}
+	return !strings.HasPrefix(origin.Pointer, "/paths/") && !strings.HasPrefix(origin.Pointer, "/webhooks/")
+}
+
+// Name returns the name of the rule
+func (r *ErrorResponsesRule) Name() string {
+	return "error_responses"
+}
+
+// Description returns the description of the rule
+func (r *ErrorResponsesRule) Description() string {
+	return "Validates that API error responses follow Solace REST API conventions as per ADR"
+}
+
+// Metadata returns the metadata of the rule
+func (r *ErrorResponsesRule) Metadata() Metadata {
+	return Metadata{Tags: []string{"errors"}}
+}
+
+// ADR returns the convention enforced by the rule
+func (r *ErrorResponsesRule) ADR() string {
+	return "- Errors must include a message, error ID, meta, and validation details\n" +
+		"- The message must be a user-friendly message detailing what went wrong\n" +
+		"- The errorId must be a UUID that was also logged with an appropriate stack trace or WARN or ERROR log\n" +
+		"- The validationDetails must describe what the issue was with the fields\n" +
+		"- The field name must match the field name being set in the request payload\n" +
+		"- If the field is an object, the validationDetails can include an object with fields nested within\n" +
+		"- If the field is an array, the array index must be indicated with square brackets beside the field name\n" +
+		"- All validation errors should be returned in a single response\n" +
+		"- Error responses reference a shared error schema rather than defining their own\n"
+}
@@ -0,0 +1,291 @@
+package rules
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/solacedev/restv2-api-server-go/internal/openapi"
+)
+
+// Severity is the severity of an issue
+type Severity string
+
+const (
+	// SeverityError marks violations of MUST statements
+	SeverityError Severity = "error"
+	// SeverityWarning marks violations of SHOULD statements
+	SeverityWarning Severity = "warning"
+	// SeverityInfo marks deviations from MAY statements and other remarks
+	SeverityInfo Severity = "info"
+	// SeverityHint marks suggestions
+	SeverityHint Severity = "hint"
+)
+
+// DefaultSeverity is the severity of rules and conditions that neither
+// declare one nor use an RFC 2119 keyword
+const DefaultSeverity = SeverityError
+
+// Severities lists the severities from most to least severe
+var Severities = []Severity{SeverityError, SeverityWarning, SeverityInfo, SeverityHint}
+
+// ParseSeverity parses a severity name
+func ParseSeverity(name string) (Severity, error) {
+	for _, severity := range Severities {
+		if string(severity) == name {
+			return severity, nil
+		}
+	}
+	return "", fmt.Errorf("unknown severity: %s", name)
+}
+
+// rank orders severities, lower being more severe
+func (s Severity) rank() int {
+	for i, severity := range Severities {
+		if s == severity {
+			return i
+		}
+	}
+	return len(Severities)
+}
+
+// MoreSevere reports whether s is more severe than other
+func (s Severity) MoreSevere(other Severity) bool {
+	return s.rank() < other.rank()
+}
+
+var (
+	mustKeywords   = regexp.MustCompile(`(?i)\b(must|shall|required)\b`)
+	shouldKeywords = regexp.MustCompile(`(?i)\b(should|recommended)\b`)
+	mayKeywords    = regexp.MustCompile(`(?i)\b(may|optional|can)\b`)
+)
+
+// SeverityFromMessage infers the severity of an ADR statement from its
+// RFC 2119 keywords, the strongest keyword winning
+func SeverityFromMessage(message string) Severity {
+	switch {
+	case mustKeywords.MatchString(message):
+		return SeverityError
+	case shouldKeywords.MatchString(message):
+		return SeverityWarning
+	case mayKeywords.MatchString(message):
+		return SeverityInfo
+	default:
+		return DefaultSeverity
+	}
+}
+
+// Issue is a finding reported by a rule
+type Issue struct {
+	Rule     string         `json:"rule"`
+	Severity Severity       `json:"severity"`
+	Message  string         `json:"message"`
+	Location openapi.Origin `json:"location"`
+	// Suggestion optionally describes how to fix the issue
+	Suggestion string `json:"suggestion,omitempty"`
+	// Category and ADRLink come from the metadata of the rule
+	Category string `json:"category,omitempty"`
+	ADRLink  string `json:"adr_link,omitempty"`
+
+	// Path, Method, Segment, Schema and Field identify the offending part of
+	// the API, when relevant
+	Path    string `json:"path,omitempty"`
+	Method  string `json:"method,omitempty"`
+	Segment string `json:"segment,omitempty"`
+	Schema  string `json:"schema,omitempty"`
+	Field   string `json:"field,omitempty"`
+
+	// Fingerprint identifies the issue across runs, see Fingerprint
+	Fingerprint string `json:"fingerprint,omitempty"`
+
+	// Suppressed issues are intentional violations declared in the spec.
+	// They are reported for auditing but don't fail the rule.
+	Suppressed  bool         `json:"suppressed,omitempty"`
+	Suppression *Suppression `json:"suppression,omitempty"`
+}
+
+// Fingerprint returns a stable identifier for an issue, computed from the
+// rule, the message, the offending part of the API and the JSON pointer of
+// the node within file. Lines and columns are left out so the fingerprint
+// survives edits elsewhere in the spec.
+func Fingerprint(issue Issue, file string) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{
+		issue.Rule,
+		issue.Message,
+		file,
+		issue.Location.Pointer,
+		issue.Path,
+		issue.Method,
+		issue.Segment,
+		issue.Schema,
+		issue.Field,
+	}, "\x00")))
+	return hex.EncodeToString(sum[:16])
+}
+
+// SortIssues sorts issues by location, then by rule and message
+func SortIssues(issues []Issue) {
+	sort.SliceStable(issues, func(i, j int) bool {
+		a, b := issues[i], issues[j]
+		switch {
+		case a.Location.File != b.Location.File:
+			return a.Location.File < b.Location.File
+		case a.Location.Line != b.Location.Line:
+			return a.Location.Line < b.Location.Line
+		case a.Location.Column != b.Location.Column:
+			return a.Location.Column < b.Location.Column
+		case a.Location.Pointer != b.Location.Pointer:
+			return a.Location.Pointer < b.Location.Pointer
+		case a.Rule != b.Rule:
+			return a.Rule < b.Rule
+		case a.Message != b.Message:
+			return a.Message < b.Message
+		case a.Path != b.Path:
+			return a.Path < b.Path
+		case a.Method != b.Method:
+			return a.Method < b.Method
+		case a.Segment != b.Segment:
+			return a.Segment < b.Segment
+		case a.Schema != b.Schema:
+			return a.Schema < b.Schema
+		default:
+			return a.Field < b.Field
+		}
+	})
+}
+
+// Statuses of a rule result
+const (
+	StatusPassed  = "passed"
+	StatusFailed  = "failed"
+	StatusError   = "error"
+	StatusSkipped = "skipped"
+)
+
+// Result is the result of applying a rule
+type Result struct {
+	Status  string  `json:"status"`
+	Message string  `json:"message,omitempty"`
+	Issues  []Issue `json:"issues,omitempty"`
+}
+
+// NewResult returns the result of a rule that reported the given issues,
+// sorted by location. The rule fails when any of them is an unsuppressed
+// error; other issues are reported without failing it.
+func NewResult(issues []Issue) *Result {
+	SortIssues(issues)
+	result := &Result{Status: StatusPassed, Issues: issues}
+	for _, issue := range issues {
+		if issue.Severity == SeverityError && !issue.Suppressed {
+			result.Status = StatusFailed
+			break
+		}
+	}
+	return result
+}
+
+// OverrideSeverity returns a copy of a result with the severity of all its
+// issues replaced, and its status updated accordingly
+func OverrideSeverity(result *Result, severity Severity) *Result {
+	if result.Status != StatusPassed && result.Status != StatusFailed {
+		return result
+	}
+
+	issues := make([]Issue, len(result.Issues))
+	for i, issue := range result.Issues {
+		issue.Severity = severity
+		issues[i] = issue
+	}
+	overridden := NewResult(issues)
+	overridden.Message = result.Message
+	return overridden
+}
+
+// ErrorResult returns the result of a rule that couldn't be applied
+func ErrorResult(format string, args ...interface{}) *Result {
+	return &Result{Status: StatusError, Message: fmt.Sprintf(format, args...)}
+}
+
+// Summary is the overall result of applying a set of rules
+type Summary struct {
+	// Status is "error" when a rule couldn't be applied, "failed" when any
+	// unsuppressed issue is an error and "passed" otherwise
+	Status  string `json:"status"`
+	Issues  int    `json:"issues"`
+	Error   int    `json:"error"`
+	Warning int    `json:"warning"`
+	Info    int    `json:"info"`
+	Hint    int    `json:"hint"`
+	// Suppressed counts the suppressed issues, which the other counts leave out
+	Suppressed int `json:"suppressed"`
+}
+
+// CategorySummary is the overall result of applying the rules of a category
+type CategorySummary struct {
+	Rules []string `json:"rules"`
+	Summary
+}
+
+// SummarizeByCategory groups a set of rule results by the category of their
+// rule, given by categories, and summarizes each group
+func SummarizeByCategory(results map[string]*Result, categories map[string]string) map[string]CategorySummary {
+	grouped := make(map[string]map[string]*Result)
+	for name, result := range results {
+		category := categories[name]
+		if category == "" {
+			category = DefaultCategory
+		}
+		if grouped[category] == nil {
+			grouped[category] = make(map[string]*Result)
+		}
+		grouped[category][name] = result
+	}
+
+	summaries := make(map[string]CategorySummary, len(grouped))
+	for category, categoryResults := range grouped {
+		var names []string
+		for name := range categoryResults {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		summaries[category] = CategorySummary{Rules: names, Summary: Summarize(categoryResults)}
+	}
+	return summaries
+}
+
+// Summarize counts the unsuppressed issues of a set of rule results by
+// severity
+func Summarize(results map[string]*Result) Summary {
+	summary := Summary{Status: StatusPassed}
+	for _, result := range results {
+		if result.Status == StatusError {
+			summary.Status = StatusError
+		}
+
+		for _, issue := range result.Issues {
+			if issue.Suppressed {
+				summary.Suppressed++
+				continue
+			}
+			summary.Issues++
+			switch issue.Severity {
+			case SeverityError:
+				summary.Error++
+			case SeverityWarning:
+				summary.Warning++
+			case SeverityInfo:
+				summary.Info++
+			case SeverityHint:
+				summary.Hint++
+			}
+		}
+	}
+
+	if summary.Status == StatusPassed && summary.Error > 0 {
+		summary.Status = StatusFailed
+	}
+	return summary
+}
@@ -1,13 +1,21 @@
 package rules
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io/fs"
 	"io/ioutil"
+	"net/url"
 	"os"
+	pathpkg "path"
 	"path/filepath"
 	"regexp"
 	"strings"
+
+	"github.com/solacedev/restv2-api-server-go/internal/openapi"
 )
 
 // JSONRule implements the Rule interface for JSON-defined rules
@@ -15,8 +23,13 @@ type JSONRule struct {
 	RuleName        string      `json:"name"`
 	RuleDescription string      `json:"description"`
 	Enabled         bool        `json:"enabled"`
+	Severity        Severity    `json:"severity,omitempty"`
 	Conditions      []Condition `json:"conditions"`
 	FilePath        string      `json:"-"` // Not part of the JSON, used for reference
+	// Version is the digest of the rule file the rule was loaded from
+	Version string `json:"-"`
+	// Metadata optionally describes the ADR behind the rule and classifies it
+	Metadata
 }
 
 // Condition represents a validation condition in a JSON rule
@@ -29,6 +42,10 @@ type Condition struct {
 	Format  string      `json:"format,omitempty"`
 	Value   interface{} `json:"value,omitempty"`
 	Message string      `json:"message"`
+	// Severity defaults to the rule's severity, or else to the severity
+	// implied by the RFC 2119 keywords of the message
+	Severity   Severity `json:"severity,omitempty"`
+	Suggestion string   `json:"suggestion,omitempty"`
 }
 
 // NewJSONRuleFromFile creates a new JSONRule from a file
@@ -39,14 +56,21 @@ func NewJSONRuleFromFile(filePath string) (*JSONRule, error) {
 		return nil, fmt.Errorf("error reading JSON rule file: %v", err)
 	}
 
+	return newJSONRule(data, filePath)
+}
+
+// newJSONRule creates a new JSONRule from the content of a rule file
+func newJSONRule(data []byte, filePath string) (*JSONRule, error) {
 	// Parse the JSON
 	var rule JSONRule
 	if err := json.Unmarshal(data, &rule); err != nil {
 		return nil, fmt.Errorf("error parsing JSON rule: %v", err)
 	}
 
-	// Set the file path
+	// Set the file path and version
 	rule.FilePath = filePath
+	sum := sha256.Sum256(data)
+	rule.Version = hex.EncodeToString(sum[:])
 
 	// Validate the rule
 	if err := rule.validate(); err != nil {
@@ -56,63 +80,98 @@ func NewJSONRuleFromFile(filePath string) (*JSONRule, error) {
 	return &rule, nil
 }
 
-// LoadJSONRulesFromDir loads all JSON rules from a directory
+// LoadJSONRulesFromDir loads all JSON rules from a directory. The directory
+// must exist.
 func LoadJSONRulesFromDir(dirPath string) (map[string]Rule, error) {
-	rules := make(map[string]Rule)
-
-	// Create the directory if it doesn't exist
-	if _, err := os.Stat(dirPath); os.IsNotExist(err) {
-		if err := os.MkdirAll(dirPath, 0755); err != nil {
-			return nil, fmt.Errorf("error creating rules directory: %v", err)
-		}
-		return rules, nil // Return empty rules since directory was just created
+	info, err := os.Stat(dirPath)
+	if err != nil {
+		return nil, fmt.Errorf("error opening rules directory: %v", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("rules directory %s is not a directory", dirPath)
 	}
 
-	// Walk the directory
-	err := filepath.Walk(dirPath, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
+	rules, err := loadJSONRules(os.DirFS(dirPath), ".", func(path string) string {
+		return filepath.Join(dirPath, filepath.FromSlash(path))
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error walking rules directory: %v", err)
+	}
+	return rules, nil
+}
 
-		// Skip directories
-		if info.IsDir() {
-			return nil
-		}
+// LoadJSONRulesFromFS loads all JSON rules from a directory of a file
+// system, such as the rules embedded in the binary. The files of the rules
+// are reported as name:path.
+func LoadJSONRulesFromFS(fsys fs.FS, dirPath, name string) (map[string]Rule, error) {
+	rules, err := loadJSONRules(fsys, dirPath, func(path string) string {
+		return name + ":" + path
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error loading %s rules: %v", name, err)
+	}
+	return rules, nil
+}
 
-		// Skip non-JSON files
-		if !strings.HasSuffix(strings.ToLower(info.Name()), ".json") {
-			return nil
-		}
+// loadJSONRules loads the JSON rules found under a directory of a file
+// system, except those of its profiles subdirectory. Rule names must be
+// unique within the directory.
+func loadJSONRules(fsys fs.FS, dirPath string, filePath func(path string) string) (map[string]Rule, error) {
+	rules := make(map[string]Rule)
 
+	profilesDir := pathpkg.Join(dirPath, ProfilesDir)
+	err := walkJSONFiles(fsys, dirPath, profilesDir, func(path string, data []byte) error {
 		// Load the rule
-		rule, err := NewJSONRuleFromFile(path)
+		rule, err := newJSONRule(data, filePath(path))
 		if err != nil {
-			return fmt.Errorf("error loading rule from %s: %v", path, err)
+			return fmt.Errorf("error loading rule from %s: %v", filePath(path), err)
 		}
 
 		// Check for name conflicts
-		baseName := rule.RuleName
-		suffix := 1
-		for {
-			if _, exists := rules[rule.RuleName]; !exists {
-				break
-			}
-			rule.RuleName = fmt.Sprintf("%s_%d", baseName, suffix)
-			suffix++
+		if existing, exists := rules[rule.RuleName]; exists {
+			return fmt.Errorf("rule %s is defined in both %s and %s", rule.RuleName, existing.(*JSONRule).FilePath, rule.FilePath)
 		}
 
 		// Add the rule
 		rules[rule.RuleName] = rule
 		return nil
 	})
-
 	if err != nil {
-		return nil, fmt.Errorf("error walking rules directory: %v", err)
+		return nil, err
 	}
 
 	return rules, nil
 }
 
+// walkJSONFiles calls load with the path and content of each JSON file
+// under a directory of a file system, skipping the skip directory
+func walkJSONFiles(fsys fs.FS, dirPath, skip string, load func(path string, data []byte) error) error {
+	return fs.WalkDir(fsys, dirPath, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		// Skip directories
+		if entry.IsDir() {
+			if path == skip {
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		// Skip non-JSON files
+		if !strings.HasSuffix(strings.ToLower(entry.Name()), ".json") {
+			return nil
+		}
+
+		data, err := fs.ReadFile(fsys, path)
+		if err != nil {
+			return fmt.Errorf("error reading JSON file %s: %v", path, err)
+		}
+		return load(path, data)
+	})
+}
+
 // validate validates the rule
 func (r *JSONRule) validate() error {
 	// Check required fields
@@ -126,6 +185,19 @@ func (r *JSONRule) validate() error {
 		return fmt.Errorf("at least one condition is required")
 	}
 
+	if r.Severity != "" {
+		if _, err := ParseSeverity(string(r.Severity)); err != nil {
+			return err
+		}
+	}
+
+	if r.ADRLink != "" {
+		link, err := url.Parse(r.ADRLink)
+		if err != nil || !link.IsAbs() {
+			return fmt.Errorf("adr_link must be an absolute URL: %s", r.ADRLink)
+		}
+	}
+
 	// Validate conditions
 	for i, condition := range r.Conditions {
 		if condition.Type == "" {
@@ -168,9 +240,36 @@ func (r *JSONRule) validate() error {
 			if condition.Field == "" {
 				return fmt.Errorf("condition %d: field is required for schema_field", i)
 			}
+			if condition.Pattern != "" {
+				if _, err := regexp.Compile(condition.Pattern); err != nil {
+					return fmt.Errorf("condition %d: invalid regex pattern: %v", i, err)
+				}
+			}
 		default:
 			return fmt.Errorf("condition %d: unknown type: %s", i, condition.Type)
 		}
+
+		// Resolve the severity of the condition
+		switch {
+		case condition.Severity != "":
+			if _, err := ParseSeverity(string(condition.Severity)); err != nil {
+				return fmt.Errorf("condition %d: %v", i, err)
+			}
+		case r.Severity != "":
+			r.Conditions[i].Severity = r.Severity
+		default:
+			r.Conditions[i].Severity = SeverityFromMessage(condition.Message)
+		}
+	}
+
+	// Rules without a severity of their own take the most severe of their conditions
+	if r.Severity == "" {
+		r.Severity = SeverityHint
+		for _, condition := range r.Conditions {
+			if condition.Severity.MoreSevere(r.Severity) {
+				r.Severity = condition.Severity
+			}
+		}
 	}
 
 	return nil
@@ -187,171 +286,134 @@ func (r *JSONRule) Description() string {
 }
 
 // Apply applies the rule to the given API spec
-func (r *JSONRule) Apply(spec map[string]interface{}) (map[string]interface{}, error) {
+func (r *JSONRule) Apply(ctx context.Context, doc *openapi.Document) (*Result, error) {
 	// Skip if the rule is disabled
 	if !r.Enabled {
-		return map[string]interface{}{
-			"status":  "skipped",
-			"message": "Rule is disabled",
-		}, nil
+		return &Result{Status: StatusSkipped, Message: "Rule is disabled"}, nil
 	}
 
-	results := make(map[string]interface{})
-	issues := []map[string]interface{}{}
+	var issues []Issue
 
 	// Check if the spec is valid
-	if spec == nil {
-		return map[string]interface{}{
-			"status":  "error",
-			"message": "invalid API spec",
-		}, nil
+	if doc == nil || doc.Spec == nil {
+		return ErrorResult("invalid API spec"), nil
 	}
 
 	// Check if the spec has paths
-	paths, ok := spec["paths"].(map[string]interface{})
-	if !ok {
-		return map[string]interface{}{
-			"status":  "error",
-			"message": "API spec does not have paths",
-		}, nil
+	if _, ok := doc.Spec["paths"].(map[string]interface{}); !ok {
+		return ErrorResult("API spec does not have paths"), nil
 	}
 
 	// Apply each condition
 	for _, condition := range r.Conditions {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
 		switch condition.Type {
 		case "path_pattern":
 			// Check all paths against the pattern
 			pattern := regexp.MustCompile(condition.Pattern)
-			for path := range paths {
-				if !pattern.MatchString(path) {
-					issues = append(issues, map[string]interface{}{
-						"path":    path,
-						"message": condition.Message,
-					})
+			for _, pathItem := range doc.Paths {
+				if !pattern.MatchString(pathItem.Path) {
+					issue := r.issue(doc, condition, condition.Message, pathItem.Node)
+					issue.Path = pathItem.Path
+					issues = append(issues, issue)
 				}
 			}
 		case "method_check":
 			// Check if the specified path has the specified method
-			pathObj, ok := paths[condition.Path].(map[string]interface{})
-			if !ok {
+			pathItem := doc.Path(condition.Path)
+			if pathItem == nil {
 				continue
 			}
-			if _, ok := pathObj[strings.ToLower(condition.Method)]; !ok {
-				issues = append(issues, map[string]interface{}{
-					"path":    condition.Path,
-					"message": condition.Message,
-				})
+			if pathItem.Operation(condition.Method) == nil {
+				issue := r.issue(doc, condition, condition.Message, pathItem.Node)
+				issue.Path = condition.Path
+				issues = append(issues, issue)
 			}
 		case "parameter_check":
-			// Check if the specified path has parameters
-			pathObj, ok := paths[condition.Path].(map[string]interface{})
-			if !ok {
+			// Check if the specified path or any of its operations has parameters
+			pathItem := doc.Path(condition.Path)
+			if pathItem == nil {
 				continue
 			}
-			if _, ok := pathObj["parameters"]; !ok {
-				issues = append(issues, map[string]interface{}{
-					"path":    condition.Path,
-					"message": condition.Message,
-				})
+			hasParameters := len(pathItem.Parameters) > 0
+			for _, op := range pathItem.Operations {
+				if len(op.Parameters) > 0 {
+					hasParameters = true
+				}
+			}
+			if !hasParameters {
+				issue := r.issue(doc, condition, condition.Message, pathItem.Node)
+				issue.Path = condition.Path
+				issues = append(issues, issue)
 			}
 		case "resource_naming":
 			// Check all paths against the resource naming pattern
 			pattern := regexp.MustCompile(condition.Pattern)
-			for path := range paths {
-				segments := strings.Split(path, "/")
-				for _, segment := range segments {
-					if segment == "" {
-						continue
-					}
+			for _, pathItem := range doc.Paths {
+				for _, segment := range pathItem.Segments() {
 					if !pattern.MatchString(segment) {
-						issues = append(issues, map[string]interface{}{
-							"path":    path,
-							"segment": segment,
-							"message": condition.Message,
-						})
+						issue := r.issue(doc, condition, condition.Message, pathItem.Node)
+						issue.Path = pathItem.Path
+						issue.Segment = segment
+						issues = append(issues, issue)
 						break
 					}
 				}
 			}
 		case "schema_field":
 			// Check if the specified field is present in the schema definitions
-			schemas, ok := spec["components"].(map[string]interface{})
-			if !ok {
-				// If there are no components, check for definitions (OpenAPI 2.0)
-				schemas, ok = spec["definitions"].(map[string]interface{})
-				if !ok {
-					// No schemas defined, add an issue
-					issues = append(issues, map[string]interface{}{
-						"field":   condition.Field,
-						"message": "No schema definitions found in API spec",
-					})
-					continue
-				}
-			} else {
-				// For OpenAPI 3.0, schemas are under components.schemas
-				schemas, ok = schemas["schemas"].(map[string]interface{})
-				if !ok {
-					// No schemas defined, add an issue
-					issues = append(issues, map[string]interface{}{
-						"field":   condition.Field,
-						"message": "No schema definitions found in API spec",
-					})
-					continue
-				}
+			if len(doc.Schemas) == 0 {
+				// No schemas defined, add an issue
+				issue := r.issue(doc, condition, "No schema definitions found in API spec", doc.Spec)
+				issue.Field = condition.Field
+				issues = append(issues, issue)
+				continue
 			}
 
-			// Check all schemas for the field
-			fieldFound := false
-			for schemaName, schemaObj := range schemas {
-				schema, ok := schemaObj.(map[string]interface{})
-				if !ok {
+			// Check every schema the condition applies to; an optional
+			// pattern restricts it to schemas whose name matches
+			var pattern *regexp.Regexp
+			if condition.Pattern != "" {
+				pattern = regexp.MustCompile(condition.Pattern)
+			}
+			for _, schemaName := range doc.SchemaNames() {
+				if pattern != nil && !pattern.MatchString(schemaName) {
 					continue
 				}
-
-				properties, ok := schema["properties"].(map[string]interface{})
-				if !ok {
+				schema := doc.Schemas[schemaName]
+				field := schema.FindProperty(condition.Field)
+				if field == nil {
+					issue := r.issue(doc, condition, condition.Message, schema.Node)
+					issue.Schema = schemaName
+					issue.Field = condition.Field
+					issues = append(issues, issue)
 					continue
 				}
 
-				if _, ok := properties[condition.Field]; ok {
-					// Field found, check format if specified
-					if condition.Format != "" {
-						fieldObj, ok := properties[condition.Field].(map[string]interface{})
-						if !ok {
-							continue
-						}
-
-						format, ok := fieldObj["format"].(string)
-						if !ok || format != condition.Format {
-							issues = append(issues, map[string]interface{}{
-								"schema":  schemaName,
-								"field":   condition.Field,
-								"message": fmt.Sprintf("%s (format should be %s)", condition.Message, condition.Format),
-							})
-						}
-					}
-					fieldFound = true
-					break
+				// Field found, check format if specified
+				if condition.Format != "" && field.Format() != condition.Format {
+					issue := r.issue(doc, condition, fmt.Sprintf("%s (format should be %s)", condition.Message, condition.Format), field.Node)
+					issue.Schema = schemaName
+					issue.Field = condition.Field
+					issues = append(issues, issue)
 				}
 			}
-
-			if !fieldFound {
-				// Field not found in any schema
-				issues = append(issues, map[string]interface{}{
-					"field":   condition.Field,
-					"message": condition.Message,
-				})
-			}
 		}
 	}
 
-	// Set the results
-	if len(issues) > 0 {
-		results["status"] = "failed"
-		results["issues"] = issues
-	} else {
-		results["status"] = "passed"
-	}
+	return NewResult(issues), nil
+}
 
-	return results, nil
+// issue returns an issue reported by a condition at the given node
+func (r *JSONRule) issue(doc *openapi.Document, condition Condition, message string, node map[string]interface{}) Issue {
+	return Issue{
+		Rule:       r.RuleName,
+		Severity:   condition.Severity,
+		Message:    message,
+		Location:   doc.Location(node),
+		Suggestion: condition.Suggestion,
+	}
 }
@@ -0,0 +1,188 @@
+package rules
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/solacedev/restv2-api-server-go/internal/openapi"
+)
+
+// paginationParameters are the query parameters of a paginated collection
+var paginationParameters = []string{"pageSize", "pageNumber"}
+
+// paginationFields are the integer fields of meta.pagination
+var paginationFields = []string{"count", "pageNumber", "pageSize", "totalPages"}
+
+// PaginationRule implements the Solace pagination rule
+type PaginationRule struct{}
+
+// NewPaginationRule creates a new PaginationRule instance
+func NewPaginationRule() *PaginationRule {
+	return &PaginationRule{}
+}
+
+// Apply checks that every collection GET operation accepts the pagination
+// query parameters and returns the pagination metadata
+func (r *PaginationRule) Apply(ctx context.Context, doc *openapi.Document) (*Result, error) {
+	var issues []Issue
+
+	// Check if the spec is valid
+	if doc == nil || doc.Spec == nil {
+		return ErrorResult("invalid API spec"), nil
+	}
+
+	// Check if the spec has paths
+	if _, ok := doc.Spec["paths"].(map[string]interface{}); !ok {
+		return ErrorResult("API spec does not have paths"), nil
+	}
+
+	for _, pathItem := range doc.Paths {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		op := pathItem.Operation("get")
+		if op == nil || !returnsCollection(op) {
+			continue
+		}
+
+		schema := successSchema(op)
+
+		issues = append(issues, r.checkParameters(doc, op)...)
+		issues = append(issues, r.checkMetadata(doc, op, schema)...)
+	}
+
+	return NewResult(issues), nil
+}
+
+// checkParameters checks that the operation accepts the integer pagination
+// query parameters
+func (r *PaginationRule) checkParameters(doc *openapi.Document, op *openapi.Operation) []Issue {
+	var issues []Issue
+	for _, name := range paginationParameters {
+		param := op.Parameter(name, "query")
+		switch {
+		case param == nil:
+			issues = append(issues, r.issue(doc, op, op.Node, name,
+				fmt.Sprintf("Collection GET operations should accept the %s query parameter", name),
+				fmt.Sprintf("Add an optional integer %s query parameter", name)))
+		case param.Schema == nil || param.Schema.Type() != "integer":
+			issues = append(issues, r.issue(doc, op, param.Node, name,
+				fmt.Sprintf("The %s query parameter should be an integer", name),
+				fmt.Sprintf("Declare the %s query parameter with type integer", name)))
+		}
+	}
+	return issues
+}
+
+// checkMetadata checks that the response of the operation has a
+// meta.pagination object with the pagination fields
+func (r *PaginationRule) checkMetadata(doc *openapi.Document, op *openapi.Operation, schema *openapi.Schema) []Issue {
+	if schema == nil {
+		node := op.Node
+		if response := op.SuccessResponse(); response != nil {
+			node = response.Node
+		}
+		return []Issue{r.issue(doc, op, node, "meta.pagination",
+			"Collection GET operations should return a JSON response with pagination information in the meta.pagination object",
+			"Add a JSON response schema with data and meta.pagination properties")}
+	}
+
+	meta := schema.FindProperty("meta")
+	if meta == nil {
+		return []Issue{r.issue(doc, op, schema.Node, "meta",
+			"Responses should include pagination information in the meta.pagination object",
+			"Add a meta object with a pagination object to the response schema")}
+	}
+	pagination := meta.FindProperty("pagination")
+	if pagination == nil {
+		return []Issue{r.issue(doc, op, meta.Node, "meta.pagination",
+			"Responses should include pagination information in the meta.pagination object",
+			"Add a pagination object to the meta object of the response schema")}
+	}
+
+	var issues []Issue
+	for _, name := range paginationFields {
+		field := pagination.FindProperty(name)
+		switch {
+		case field == nil:
+			issues = append(issues, r.issue(doc, op, pagination.Node, "meta.pagination."+name,
+				fmt.Sprintf("The pagination object should include the %s field", name),
+				fmt.Sprintf("Add an integer %s property to meta.pagination", name)))
+		case field.ComposedType() != "integer":
+			issues = append(issues, r.issue(doc, op, field.Node, "meta.pagination."+name,
+				fmt.Sprintf("The %s pagination field should be an integer", name),
+				fmt.Sprintf("Declare meta.pagination.%s with type integer", name)))
+		}
+	}
+
+	nextPage := pagination.FindProperty("nextPage")
+	switch {
+	case nextPage == nil:
+		issues = append(issues, r.issue(doc, op, pagination.Node, "meta.pagination.nextPage",
+			"The pagination object should include the nextPage field",
+			"Add a nullable integer nextPage property to meta.pagination"))
+	case nextPage.AcceptedType() != "integer" || !nextPage.AcceptsNull():
+		issues = append(issues, r.issue(doc, op, nextPage.Node, "meta.pagination.nextPage",
+			"The nextPage pagination field should be an integer, or null if there are no other pages",
+			"Declare meta.pagination.nextPage as a nullable integer"))
+	}
+
+	return issues
+}
+
+// issue returns an issue reported on a collection GET operation
+func (r *PaginationRule) issue(doc *openapi.Document, op *openapi.Operation, node map[string]interface{}, field, message, suggestion string) Issue {
+	return Issue{
+		Rule:       r.Name(),
+		Severity:   SeverityFromMessage(message),
+		Message:    message,
+		Location:   doc.Location(node),
+		Suggestion: suggestion,
+		Path:       op.Path,
+		Method:     op.Method,
+		Field:      field,
+	}
+}
+
+// successSchema returns the JSON schema of the success response of an
+// operation, if any
+func successSchema(op *openapi.Operation) *openapi.Schema {
+	response := op.SuccessResponse()
+	if response == nil {
+		return nil
+	}
+	return response.JSONSchema()
+}
+
+// Name returns the name of the rule
+func (r *PaginationRule) Name() string {
+	return "pagination"
+}
+
+// Description returns the description of the rule
+func (r *PaginationRule) Description() string {
+	return "Validates that collection GET operations are paginated as per ADR"
+}
+
+// Severity returns the severity of the most severe issues of the rule: the
+// pagination ADR only makes recommendations
+func (r *PaginationRule) Severity() Severity {
+	return SeverityWarning
+}
+
+// Metadata returns the metadata of the rule
+func (r *PaginationRule) Metadata() Metadata {
+	return Metadata{Tags: []string{"pagination", "query"}}
+}
+
+// ADR returns the convention enforced by the rule
+func (r *PaginationRule) ADR() string {
+	return "- REST APIs should accept pageSize and pageNumber request parameters\n" +
+		"- Responses should include pagination information in the meta.pagination object\n" +
+		"- The count field should be an integer representing the total number of elements available across all pages\n" +
+		"- The pageNumber field should be an integer representing the current page number (starts at 1, not 0)\n" +
+		"- The pageSize field should be an integer representing the page size requested by the user or the default page size\n" +
+		"- The nextPage field should be an integer representing the next page available with results, or null if there are no other pages\n" +
+		"- The totalPages field should be an integer representing the total number of pages\n"
+}
@@ -0,0 +1,165 @@
+package rules
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/solacedev/restv2-api-server-go/internal/openapi"
+)
+
+// PayloadStructureRule implements the Solace payload structure rule
+type PayloadStructureRule struct{}
+
+// NewPayloadStructureRule creates a new PayloadStructureRule instance
+func NewPayloadStructureRule() *PayloadStructureRule {
+	return &PayloadStructureRule{}
+}
+
+// Apply checks that success responses wrap their payload in a data
+// envelope with metadata in meta, and that request bodies aren't wrapped
+func (r *PayloadStructureRule) Apply(ctx context.Context, doc *openapi.Document) (*Result, error) {
+	var issues []Issue
+
+	// Check if the spec is valid
+	if doc == nil || doc.Spec == nil {
+		return ErrorResult("invalid API spec"), nil
+	}
+
+	// Check if the spec has paths
+	if _, ok := doc.Spec["paths"].(map[string]interface{}); !ok {
+		return ErrorResult("API spec does not have paths"), nil
+	}
+
+	// Checks of a schema's own structure are made once per schema, since
+	// schemas can be shared by operations
+	requests := make(map[openapi.Origin]bool)
+	responses := make(map[openapi.Origin]bool)
+	once := func(checked map[openapi.Origin]bool, schema *openapi.Schema) bool {
+		origin := schema.Origin()
+		if checked[origin] {
+			return false
+		}
+		checked[origin] = true
+		return true
+	}
+
+	for _, op := range doc.Operations() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		if op.RequestBody != nil {
+			if schema := op.RequestBody.JSONSchema(); schema != nil && once(requests, schema) {
+				if data := schema.FindProperty("data"); data != nil {
+					issues = append(issues, r.issue(doc, op, schema, data.Node, "data",
+						"Requests must not use a data envelope",
+						"Send the resource's fields at the top level of the request body"))
+				}
+			}
+		}
+
+		for _, response := range op.Responses {
+			if !response.IsSuccess() {
+				continue
+			}
+			schema := response.JSONSchema()
+			if schema == nil {
+				continue
+			}
+			issues = append(issues, r.checkResponse(doc, op, response, schema, once(responses, schema))...)
+		}
+	}
+
+	return NewResult(issues), nil
+}
+
+// checkResponse checks the envelope of a success response. Whether data
+// must be an array depends on the operation, so it is checked every time;
+// the rest of the schema is only checked when first is set.
+func (r *PayloadStructureRule) checkResponse(doc *openapi.Document, op *openapi.Operation, response *openapi.Response, schema *openapi.Schema, first bool) []Issue {
+	var issues []Issue
+
+	data := schema.FindProperty("data")
+	if data == nil {
+		if first {
+			issues = append(issues, r.issue(doc, op, schema, schema.Node, "data",
+				"All responses must include an envelope containing a data field for the resulting objects",
+				"Wrap the response payload in a data property"))
+		}
+	} else if isArray := data.ComposedType() == "array"; returnsCollection(op) != isArray {
+		message := fmt.Sprintf("The data field of the %s response of a single resource must be an object", response.Code)
+		suggestion := "Declare data as the resource object"
+		if !isArray {
+			message = fmt.Sprintf("The data field of the %s response of a collection must be an array", response.Code)
+			suggestion = "Declare data as an array of the collection's resources"
+		}
+		issue := r.issue(doc, op, schema, data.Node, "data", message, suggestion)
+		issue.Path, issue.Method = op.Path, op.Method
+		issues = append(issues, issue)
+	}
+
+	// Without an envelope, the other top-level properties are the payload
+	if !first || data == nil {
+		return issues
+	}
+
+	if meta := schema.FindProperty("meta"); meta != nil {
+		if kind := meta.ComposedType(); kind != "" && kind != "object" {
+			issues = append(issues, r.issue(doc, op, schema, meta.Node, "meta",
+				"Metadata about the response (ex. pagination) must be in a meta JSON object",
+				"Declare meta as an object"))
+		}
+	}
+
+	for _, name := range schema.AllPropertyNames() {
+		if name == "data" || name == "meta" {
+			continue
+		}
+		issues = append(issues, r.issue(doc, op, schema, schema.FindProperty(name).Node, name,
+			fmt.Sprintf("Responses must only have data and meta at the top level, not %s", name),
+			fmt.Sprintf("Move %s into data, or into meta if it describes the response", name)))
+	}
+
+	return issues
+}
+
+// issue returns an issue about a field of a request or response schema.
+// Issues about shared schemas aren't tied to the operation they were found on.
+func (r *PayloadStructureRule) issue(doc *openapi.Document, op *openapi.Operation, schema *openapi.Schema, node map[string]interface{}, field, message, suggestion string) Issue {
+	issue := Issue{
+		Rule:       r.Name(),
+		Severity:   SeverityFromMessage(message),
+		Message:    message,
+		Location:   doc.Location(node),
+		Suggestion: suggestion,
+		Schema:     schema.Name(),
+		Field:      field,
+	}
+	if issue.Schema == "" {
+		issue.Path, issue.Method = op.Path, op.Method
+	}
+	return issue
+}
+
+// Name returns the name of the rule
+func (r *PayloadStructureRule) Name() string {
+	return "payload_structure"
+}
+
+// Description returns the description of the rule
+func (r *PayloadStructureRule) Description() string {
+	return "Validates that API payload structure follows Solace REST API conventions as per ADR"
+}
+
+// Metadata returns the metadata of the rule
+func (r *PayloadStructureRule) Metadata() Metadata {
+	return Metadata{Tags: []string{"payload"}}
+}
+
+// ADR returns the convention enforced by the rule
+func (r *PayloadStructureRule) ADR() string {
+	return "- All responses must include an envelope containing a data field for the resulting objects\n" +
+		"- The data field is an array for collections and an object for single resources\n" +
+		"- Requests must not use a data envelope\n" +
+		"- Metadata about the response (ex. pagination) must be in a meta JSON object\n"
+}
@@ -0,0 +1,243 @@
+package rules
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	pathpkg "path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ProfilesDir is the subdirectory of a rules directory holding profiles
+const ProfilesDir = "profiles"
+
+// Profile is a named selection of rules with severity overrides, so that
+// different kinds of APIs can be validated against different expectations
+type Profile struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// Extends names the profiles whose selections and severities this one
+	// starts from, later profiles overriding earlier severities
+	Extends []string `json:"extends,omitempty"`
+	// Include adds rules to the selection. A profile that neither extends
+	// nor includes anything starts from all rules.
+	Include *Selector `json:"include,omitempty"`
+	// Exclude removes rules from the selection
+	Exclude *Selector `json:"exclude,omitempty"`
+	// Severities overrides the severity of the issues of the named rules
+	Severities map[string]Severity `json:"severities,omitempty"`
+	FilePath   string              `json:"-"`
+	// Version is the digest of the profile file the profile was loaded from
+	Version string `json:"-"`
+}
+
+// Selector matches rules by name or by tag
+type Selector struct {
+	Rules []string `json:"rules,omitempty"`
+	Tags  []string `json:"tags,omitempty"`
+}
+
+// matches reports whether a rule is named by the selector or has one of its tags
+func (s *Selector) matches(rule Rule) bool {
+	if s == nil {
+		return false
+	}
+	for _, name := range s.Rules {
+		if name == rule.Name() {
+			return true
+		}
+	}
+	for _, tag := range s.Tags {
+		if HasTag(rule, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// Selection is the set of rules to apply in a validation, with the
+// severities that override those of the rules' issues
+type Selection struct {
+	// Profile is the name of the profile the selection was made by, if any
+	Profile    string
+	Rules      []string
+	Severities map[string]Severity
+}
+
+// Version identifies the selection and the versions of the definitions of
+// its rules. Unknown rules are included by name.
+func (s Selection) Version(ruleSet map[string]Rule) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00", s.Profile)
+	for _, name := range s.Rules {
+		version := ""
+		if rule, ok := ruleSet[name]; ok {
+			version = Version(rule)
+		}
+		fmt.Fprintf(h, "%s\x00%s\x00%s\x00", name, version, s.Severities[name])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// newProfile creates a new Profile from the content of a profile file
+func newProfile(data []byte, filePath string) (*Profile, error) {
+	var profile Profile
+	if err := json.Unmarshal(data, &profile); err != nil {
+		return nil, fmt.Errorf("error parsing profile: %v", err)
+	}
+
+	profile.FilePath = filePath
+	sum := sha256.Sum256(data)
+	profile.Version = hex.EncodeToString(sum[:])
+
+	if profile.Name == "" {
+		return nil, fmt.Errorf("invalid profile: profile name is required")
+	}
+	for ruleName, severity := range profile.Severities {
+		if _, err := ParseSeverity(string(severity)); err != nil {
+			return nil, fmt.Errorf("invalid profile: rule %s: %v", ruleName, err)
+		}
+	}
+
+	return &profile, nil
+}
+
+// LoadProfilesFromDir loads the profiles of the profiles subdirectory of a
+// rules directory, if it has one
+func LoadProfilesFromDir(dirPath string) (map[string]*Profile, error) {
+	profiles, err := loadProfiles(os.DirFS(dirPath), ProfilesDir, func(path string) string {
+		return filepath.Join(dirPath, filepath.FromSlash(path))
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error loading profiles: %v", err)
+	}
+	return profiles, nil
+}
+
+// LoadProfilesFromFS loads the profiles of the profiles subdirectory of a
+// rules directory of a file system, if it has one. The files of the
+// profiles are reported as name:path.
+func LoadProfilesFromFS(fsys fs.FS, dirPath, name string) (map[string]*Profile, error) {
+	profiles, err := loadProfiles(fsys, pathpkg.Join(dirPath, ProfilesDir), func(path string) string {
+		return name + ":" + path
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error loading %s profiles: %v", name, err)
+	}
+	return profiles, nil
+}
+
+// loadProfiles loads the profiles found under a directory of a file system.
+// Profile names must be unique within the directory.
+func loadProfiles(fsys fs.FS, dirPath string, filePath func(path string) string) (map[string]*Profile, error) {
+	profiles := make(map[string]*Profile)
+
+	if _, err := fs.Stat(fsys, dirPath); os.IsNotExist(err) {
+		return profiles, nil
+	}
+
+	err := walkJSONFiles(fsys, dirPath, "", func(path string, data []byte) error {
+		profile, err := newProfile(data, filePath(path))
+		if err != nil {
+			return fmt.Errorf("error loading profile from %s: %v", filePath(path), err)
+		}
+
+		if existing, exists := profiles[profile.Name]; exists {
+			return fmt.Errorf("profile %s is defined in both %s and %s", profile.Name, existing.FilePath, profile.FilePath)
+		}
+
+		profiles[profile.Name] = profile
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return profiles, nil
+}
+
+// ResolveProfile resolves the named profile into the selection of rules of
+// a rule set it stands for
+func ResolveProfile(name string, profiles map[string]*Profile, ruleSet map[string]Rule) (Selection, error) {
+	selected, severities, err := resolveProfile(name, profiles, ruleSet, nil)
+	if err != nil {
+		return Selection{}, err
+	}
+
+	selection := Selection{Profile: name, Severities: make(map[string]Severity)}
+	for ruleName := range selected {
+		selection.Rules = append(selection.Rules, ruleName)
+		if severity, ok := severities[ruleName]; ok {
+			selection.Severities[ruleName] = severity
+		}
+	}
+	sort.Strings(selection.Rules)
+	return selection, nil
+}
+
+// resolveProfile returns the rules selected by a profile and their severity
+// overrides. Visiting holds the profiles being resolved, to detect cycles.
+func resolveProfile(name string, profiles map[string]*Profile, ruleSet map[string]Rule, visiting []string) (map[string]bool, map[string]Severity, error) {
+	for _, visited := range visiting {
+		if visited == name {
+			return nil, nil, fmt.Errorf("profile %s extends itself: %s -> %s", name, strings.Join(visiting, " -> "), name)
+		}
+	}
+	profile, ok := profiles[name]
+	if !ok {
+		if len(visiting) > 0 {
+			return nil, nil, fmt.Errorf("profile %s extends unknown profile %s", visiting[len(visiting)-1], name)
+		}
+		return nil, nil, fmt.Errorf("unknown profile: %s", name)
+	}
+	visiting = append(visiting, name)
+
+	selected := make(map[string]bool)
+	severities := make(map[string]Severity)
+
+	// Start from the extended profiles
+	for _, parent := range profile.Extends {
+		parentSelected, parentSeverities, err := resolveProfile(parent, profiles, ruleSet, visiting)
+		if err != nil {
+			return nil, nil, err
+		}
+		for ruleName := range parentSelected {
+			selected[ruleName] = true
+		}
+		for ruleName, severity := range parentSeverities {
+			severities[ruleName] = severity
+		}
+	}
+
+	// Include the rules matching the profile, or all of them
+	if profile.Include != nil {
+		for _, ruleName := range profile.Include.Rules {
+			if _, ok := ruleSet[ruleName]; !ok {
+				return nil, nil, fmt.Errorf("profile %s includes unknown rule %s", name, ruleName)
+			}
+		}
+	}
+	for ruleName, rule := range ruleSet {
+		if profile.Include.matches(rule) || (profile.Include == nil && len(profile.Extends) == 0) {
+			selected[ruleName] = true
+		}
+	}
+
+	// Exclude the rules matching the profile
+	for ruleName, rule := range ruleSet {
+		if profile.Exclude.matches(rule) {
+			delete(selected, ruleName)
+		}
+	}
+
+	for ruleName, severity := range profile.Severities {
+		severities[ruleName] = severity
+	}
+
+	return selected, severities, nil
+}
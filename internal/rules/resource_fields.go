@@ -0,0 +1,234 @@
+package rules
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/solacedev/restv2-api-server-go/internal/openapi"
+)
+
+// resourceField is a field every resource DTO must declare
+type resourceField struct {
+	name string
+	// format is the required format of the field, if any
+	format  string
+	message string
+	// formatMessage is the statement reported for a field of the wrong format
+	formatMessage string
+}
+
+// standardFields are the fields identifying every resource
+var standardFields = []resourceField{
+	{name: "id", message: "must have an 'id' field that represents the opaque ID of the object"},
+	{name: "type", message: "must have a 'type' field that uniquely identifies the type of object being returned"},
+}
+
+// auditFields are the fields tracking who changed a resource and when
+var auditFields = []resourceField{
+	{name: "createdBy", message: "must include a 'createdBy' field to track the user ID that created the entity"},
+	{name: "createdTime", format: "date-time", message: "must include a 'createdTime' field in ISO 8601 format (yyyy-MM-dd'T'HH:mm:ss.SSS'Z')",
+		formatMessage: "must declare its 'createdTime' field as a string with format date-time"},
+	{name: "updatedBy", message: "must include an 'updatedBy' field to track the user ID that last modified the entity"},
+	{name: "updatedTime", format: "date-time", message: "must include an 'updatedTime' field in ISO 8601 format (yyyy-MM-dd'T'HH:mm:ss.SSS'Z')",
+		formatMessage: "must declare its 'updatedTime' field as a string with format date-time"},
+}
+
+// resourceDTO is a schema returned as a resource by a GET operation
+type resourceDTO struct {
+	schema *openapi.Schema
+	// op is the first operation returning the schema
+	op *openapi.Operation
+}
+
+// resourceDTOs returns the schemas returned as resources by the GET
+// operations of a document, each once
+func resourceDTOs(doc *openapi.Document) []resourceDTO {
+	var dtos []resourceDTO
+	seen := make(map[openapi.Origin]bool)
+	for _, op := range doc.Operations() {
+		if op.Method != "get" {
+			continue
+		}
+		for _, schema := range resourceSchemas(op) {
+			origin := schema.Origin()
+			if seen[origin] {
+				continue
+			}
+			seen[origin] = true
+			dtos = append(dtos, resourceDTO{schema: schema, op: op})
+		}
+	}
+	return dtos
+}
+
+// resourceSchemas returns the schemas of the resources returned by a GET
+// operation: its data, or the items of a data array. Responses without a
+// data envelope return the resource itself or, for collections, a wrapper
+// holding the resources in its array properties.
+func resourceSchemas(op *openapi.Operation) []*openapi.Schema {
+	schema := successSchema(op)
+	if schema == nil {
+		return nil
+	}
+
+	if data := schema.FindProperty("data"); data != nil {
+		schema = data
+	} else if schema.ComposedType() != "array" && returnsCollection(op) {
+		var items []*openapi.Schema
+		for _, name := range schema.AllPropertyNames() {
+			property := schema.FindProperty(name)
+			if property.ComposedType() == "array" && property.Items() != nil {
+				items = append(items, property.Items())
+			}
+		}
+		if len(items) > 0 {
+			return items
+		}
+	}
+
+	if schema.ComposedType() == "array" {
+		if schema = schema.Items(); schema == nil {
+			return nil
+		}
+	}
+	if t := schema.ComposedType(); t != "" && t != "object" {
+		return nil
+	}
+	return []*openapi.Schema{schema}
+}
+
+// checkResourceFields reports the fields each resource DTO of a document
+// misses, or declares with the wrong format
+func checkResourceFields(ctx context.Context, doc *openapi.Document, rule string, fields []resourceField) (*Result, error) {
+	var issues []Issue
+
+	// Check if the spec is valid
+	if doc == nil || doc.Spec == nil {
+		return ErrorResult("invalid API spec"), nil
+	}
+
+	// Check if the spec has paths
+	if _, ok := doc.Spec["paths"].(map[string]interface{}); !ok {
+		return ErrorResult("API spec does not have paths"), nil
+	}
+
+	for _, dto := range resourceDTOs(doc) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		// Inline schemas are named after the operation returning them
+		subject := "Schema " + dto.schema.Name()
+		if dto.schema.Name() == "" {
+			subject = fmt.Sprintf("The resource returned by GET %s", dto.op.Path)
+		}
+
+		for _, field := range fields {
+			// The severity follows the ADR statement, whatever the subject
+			issue := Issue{
+				Rule:     rule,
+				Severity: SeverityFromMessage(field.message),
+				Schema:   dto.schema.Name(),
+				Field:    field.name,
+			}
+			if issue.Schema == "" {
+				issue.Path, issue.Method = dto.op.Path, dto.op.Method
+			}
+
+			property := dto.schema.FindProperty(field.name)
+			switch {
+			case property == nil:
+				issue.Message = fmt.Sprintf("%s %s", subject, field.message)
+				issue.Location = doc.Location(dto.schema.Node)
+				issue.Suggestion = fmt.Sprintf("Add the %s field to the resource", field.name)
+			case field.format != "" && (property.ComposedType() != "string" || property.Format() != field.format):
+				issue.Message = fmt.Sprintf("%s %s", subject, field.formatMessage)
+				issue.Location = doc.Location(property.Node)
+				issue.Suggestion = fmt.Sprintf("Declare %s as a string with format %s", field.name, field.format)
+			default:
+				continue
+			}
+			issues = append(issues, issue)
+		}
+	}
+
+	return NewResult(issues), nil
+}
+
+// resourceFieldsADR lists the statements enforced for fields
+func resourceFieldsADR(subject string, fields []resourceField) string {
+	var adr strings.Builder
+	for _, field := range fields {
+		fmt.Fprintf(&adr, "- %s %s\n", subject, field.message)
+	}
+	return adr.String()
+}
+
+// StandardFieldsRule implements the Solace standard fields rule
+type StandardFieldsRule struct{}
+
+// NewStandardFieldsRule creates a new StandardFieldsRule instance
+func NewStandardFieldsRule() *StandardFieldsRule {
+	return &StandardFieldsRule{}
+}
+
+// Apply checks that every resource DTO returned by a GET operation has the
+// standard fields
+func (r *StandardFieldsRule) Apply(ctx context.Context, doc *openapi.Document) (*Result, error) {
+	return checkResourceFields(ctx, doc, r.Name(), standardFields)
+}
+
+// Name returns the name of the rule
+func (r *StandardFieldsRule) Name() string {
+	return "standard_fields"
+}
+
+// Description returns the description of the rule
+func (r *StandardFieldsRule) Description() string {
+	return "Validates that API resources include standard fields as per ADR"
+}
+
+// Metadata returns the metadata of the rule
+func (r *StandardFieldsRule) Metadata() Metadata {
+	return Metadata{Tags: []string{"fields"}}
+}
+
+// ADR returns the convention enforced by the rule
+func (r *StandardFieldsRule) ADR() string {
+	return resourceFieldsADR("Every REST resource", standardFields)
+}
+
+// AuditFieldsRule implements the Solace audit fields rule
+type AuditFieldsRule struct{}
+
+// NewAuditFieldsRule creates a new AuditFieldsRule instance
+func NewAuditFieldsRule() *AuditFieldsRule {
+	return &AuditFieldsRule{}
+}
+
+// Apply checks that every resource DTO returned by a GET operation has the
+// audit fields
+func (r *AuditFieldsRule) Apply(ctx context.Context, doc *openapi.Document) (*Result, error) {
+	return checkResourceFields(ctx, doc, r.Name(), auditFields)
+}
+
+// Name returns the name of the rule
+func (r *AuditFieldsRule) Name() string {
+	return "audit_fields"
+}
+
+// Description returns the description of the rule
+func (r *AuditFieldsRule) Description() string {
+	return "Validates that DTOs include standard audit fields (createdBy, createdTime, updatedBy, updatedTime) as per ADR"
+}
+
+// Metadata returns the metadata of the rule
+func (r *AuditFieldsRule) Metadata() Metadata {
+	return Metadata{Tags: []string{"fields"}}
+}
+
+// ADR returns the convention enforced by the rule
+func (r *AuditFieldsRule) ADR() string {
+	return resourceFieldsADR("DTOs", auditFields)
+}
@@ -1,13 +1,18 @@
 package rules
 
 import (
+	"context"
 	"fmt"
 	"strings"
+
+	"github.com/solacedev/restv2-api-server-go/internal/openapi"
 )
 
 // Rule defines the interface for a validation rule
 type Rule interface {
-	Apply(spec map[string]interface{}) (map[string]interface{}, error)
+	// Apply applies the rule to the dereferenced document. Long-running
+	// rules must stop and return the context's error once it is cancelled.
+	Apply(ctx context.Context, doc *openapi.Document) (*Result, error)
 	Name() string
 	Description() string
 }
@@ -21,70 +26,44 @@ func NewSolaceRestRules() *SolaceRestRules {
 }
 
 // Apply applies the Solace REST API rules to the given API spec
-func (r *SolaceRestRules) Apply(spec map[string]interface{}) (map[string]interface{}, error) {
-	results := make(map[string]interface{})
-	issues := []map[string]interface{}{}
+func (r *SolaceRestRules) Apply(ctx context.Context, doc *openapi.Document) (*Result, error) {
+	var issues []Issue
 
 	// Check if the spec is valid
-	if spec == nil {
-		return map[string]interface{}{
-			"status":  "error",
-			"message": "invalid API spec",
-		}, nil
+	if doc == nil || doc.Spec == nil {
+		return ErrorResult("invalid API spec"), nil
 	}
 
 	// Check if the spec has paths
-	paths, ok := spec["paths"].(map[string]interface{})
-	if !ok {
-		return map[string]interface{}{
-			"status":  "error",
-			"message": "API spec does not have paths",
-		}, nil
+	if _, ok := doc.Spec["paths"].(map[string]interface{}); !ok {
+		return ErrorResult("API spec does not have paths"), nil
 	}
 
 	// Check if the paths follow REST conventions
-	for path, pathObj := range paths {
-		pathItem, ok := pathObj.(map[string]interface{})
-		if !ok {
-			continue
+	for _, pathItem := range doc.Paths {
+		if err := ctx.Err(); err != nil {
+			return nil, err
 		}
 
-		// Check HTTP methods
-		for method, methodObj := range pathItem {
-			if method == "parameters" || method == "summary" || method == "description" {
-				continue
-			}
-
-			methodDef, ok := methodObj.(map[string]interface{})
-			if !ok {
-				continue
-			}
-
+		// Check the operations; other path item keys such as servers or
+		// extensions are not part of the model
+		for _, op := range pathItem.Operations {
 			// Check if the method is appropriate for the path
-			if issue := r.checkMethodPathConsistency(path, method, methodDef); issue != nil {
-				issues = append(issues, issue)
+			if issue := r.checkMethodPathConsistency(doc, op); issue != nil {
+				issues = append(issues, *issue)
 			}
 		}
 	}
 
-	// Set the results
-	if len(issues) > 0 {
-		results["status"] = "failed"
-		results["issues"] = issues
-	} else {
-		results["status"] = "passed"
-	}
-
-	return results, nil
+	return NewResult(issues), nil
 }
 
 // checkMethodPathConsistency checks if the HTTP method is appropriate for the path
-func (r *SolaceRestRules) checkMethodPathConsistency(path, method string, methodDef map[string]interface{}) map[string]interface{} {
-	// Convert method to lowercase for comparison
-	method = strings.ToLower(method)
+func (r *SolaceRestRules) checkMethodPathConsistency(doc *openapi.Document, op *openapi.Operation) *Issue {
+	path, method := op.Path, op.Method
 
 	// Check if the path ends with an ID parameter
-	endsWithID := strings.Contains(path, "/{") && strings.HasSuffix(path, "}")
+	endsWithID := op.PathItem.EndsWithParameter()
 
 	// Rules for REST API methods
 	switch method {
@@ -94,19 +73,27 @@ func (r *SolaceRestRules) checkMethodPathConsistency(path, method string, method
 	case "post":
 		// POST should be used for collection paths
 		if endsWithID {
-			return map[string]interface{}{
-				"path":    path,
-				"method":  method,
-				"message": "POST should be used for collection paths, not for specific resources",
+			return &Issue{
+				Rule:       r.Name(),
+				Severity:   SeverityError,
+				Message:    "POST should be used for collection paths, not for specific resources",
+				Location:   doc.Location(op.Node),
+				Suggestion: "Move the POST operation to the collection path",
+				Path:       path,
+				Method:     method,
 			}
 		}
 	case "put", "patch", "delete":
 		// PUT, PATCH, DELETE should be used for resource paths
 		if !endsWithID {
-			return map[string]interface{}{
-				"path":    path,
-				"method":  method,
-				"message": fmt.Sprintf("%s should be used for specific resources, not for collections", strings.ToUpper(method)),
+			return &Issue{
+				Rule:       r.Name(),
+				Severity:   SeverityError,
+				Message:    fmt.Sprintf("%s should be used for specific resources, not for collections", strings.ToUpper(method)),
+				Location:   doc.Location(op.Node),
+				Suggestion: fmt.Sprintf("Move the %s operation to the resource path %s/{id}", strings.ToUpper(method), strings.TrimSuffix(path, "/")),
+				Path:       path,
+				Method:     method,
 			}
 		}
 	}
@@ -124,6 +111,19 @@ func (r *SolaceRestRules) Description() string {
 	return "Validates that the API follows Solace REST API conventions"
 }
 
+// Metadata returns the metadata of the rule
+func (r *SolaceRestRules) Metadata() Metadata {
+	return Metadata{Tags: []string{"methods", "paths"}}
+}
+
+// ADR returns the convention enforced by the rule
+func (r *SolaceRestRules) ADR() string {
+	return "HTTP methods must match the kind of path they are used on. POST is used on collection paths " +
+		"(e.g. /api/v2/platform/environments) to create resources. PUT, PATCH and DELETE are used on " +
+		"resource paths ending with an ID parameter (e.g. /api/v2/platform/environments/{id}). GET is " +
+		"valid on both."
+}
+
 // SolaceSingularUserResourcesRule implements the Solace singular user resources rule
 type SolaceSingularUserResourcesRule struct{}
 
@@ -133,47 +133,40 @@ func NewSolaceSingularUserResourcesRule() *SolaceSingularUserResourcesRule {
 }
 
 // Apply applies the Solace singular user resources rule to the given API spec
-func (r *SolaceSingularUserResourcesRule) Apply(spec map[string]interface{}) (map[string]interface{}, error) {
-	results := make(map[string]interface{})
-	issues := []map[string]interface{}{}
+func (r *SolaceSingularUserResourcesRule) Apply(ctx context.Context, doc *openapi.Document) (*Result, error) {
+	var issues []Issue
 
 	// Check if the spec is valid
-	if spec == nil {
-		return map[string]interface{}{
-			"status":  "error",
-			"message": "invalid API spec",
-		}, nil
+	if doc == nil || doc.Spec == nil {
+		return ErrorResult("invalid API spec"), nil
 	}
 
 	// Check if the spec has paths
-	paths, ok := spec["paths"].(map[string]interface{})
-	if !ok {
-		return map[string]interface{}{
-			"status":  "error",
-			"message": "API spec does not have paths",
-		}, nil
+	if _, ok := doc.Spec["paths"].(map[string]interface{}); !ok {
+		return ErrorResult("API spec does not have paths"), nil
 	}
 
 	// Check for user-specific resources
-	for path := range paths {
+	for _, pathItem := range doc.Paths {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		path := pathItem.Path
 		if strings.Contains(path, "/users/") && !strings.Contains(path, "/me/") {
 			// This is a user-specific resource that doesn't use /me/
-			issues = append(issues, map[string]interface{}{
-				"path":    path,
-				"message": "User-specific resources should use /me/ instead of /users/{id}",
+			issues = append(issues, Issue{
+				Rule:       r.Name(),
+				Severity:   SeverityError,
+				Message:    "User-specific resources should use /me/ instead of /users/{id}",
+				Location:   doc.Location(pathItem.Node),
+				Suggestion: "Address the resources of the current user through /me/",
+				Path:       path,
 			})
 		}
 	}
 
-	// Set the results
-	if len(issues) > 0 {
-		results["status"] = "failed"
-		results["issues"] = issues
-	} else {
-		results["status"] = "passed"
-	}
-
-	return results, nil
+	return NewResult(issues), nil
 }
 
 // Name returns the name of the rule
@@ -186,6 +179,17 @@ func (r *SolaceSingularUserResourcesRule) Description() string {
 	return "Validates that user-specific resources use /me/ instead of /users/{id}"
 }
 
+// Metadata returns the metadata of the rule
+func (r *SolaceSingularUserResourcesRule) Metadata() Metadata {
+	return Metadata{Tags: []string{"naming", "paths"}}
+}
+
+// ADR returns the convention enforced by the rule
+func (r *SolaceSingularUserResourcesRule) ADR() string {
+	return "Resources owned by the currently logged in user are addressed through /me/ " +
+		"(e.g. /api/v2/platform/me/preferences) instead of /users/{id}."
+}
+
 // SolaceCustomActionsRule implements the Solace custom actions rule
 type SolaceCustomActionsRule struct{}
 
@@ -195,55 +199,42 @@ func NewSolaceCustomActionsRule() *SolaceCustomActionsRule {
 }
 
 // Apply applies the Solace custom actions rule to the given API spec
-func (r *SolaceCustomActionsRule) Apply(spec map[string]interface{}) (map[string]interface{}, error) {
-	results := make(map[string]interface{})
-	issues := []map[string]interface{}{}
+func (r *SolaceCustomActionsRule) Apply(ctx context.Context, doc *openapi.Document) (*Result, error) {
+	var issues []Issue
 
 	// Check if the spec is valid
-	if spec == nil {
-		return map[string]interface{}{
-			"status":  "error",
-			"message": "invalid API spec",
-		}, nil
+	if doc == nil || doc.Spec == nil {
+		return ErrorResult("invalid API spec"), nil
 	}
 
 	// Check if the spec has paths
-	paths, ok := spec["paths"].(map[string]interface{})
-	if !ok {
-		return map[string]interface{}{
-			"status":  "error",
-			"message": "API spec does not have paths",
-		}, nil
+	if _, ok := doc.Spec["paths"].(map[string]interface{}); !ok {
+		return ErrorResult("API spec does not have paths"), nil
 	}
 
 	// Check for custom actions
-	for path, pathObj := range paths {
-		pathItem, ok := pathObj.(map[string]interface{})
-		if !ok {
-			continue
+	for _, pathItem := range doc.Paths {
+		if err := ctx.Err(); err != nil {
+			return nil, err
 		}
 
 		// Check if the path contains a custom action
-		if strings.Contains(path, "/actions/") {
+		if strings.Contains(pathItem.Path, "/actions/") {
 			// Check if the action is properly defined
-			if _, ok := pathItem["post"]; !ok {
-				issues = append(issues, map[string]interface{}{
-					"path":    path,
-					"message": "Custom actions should use POST method",
+			if pathItem.Operation("post") == nil {
+				issues = append(issues, Issue{
+					Rule:       r.Name(),
+					Severity:   SeverityError,
+					Message:    "Custom actions should use POST method",
+					Location:   doc.Location(pathItem.Node),
+					Suggestion: "Invoke the action with a POST operation",
+					Path:       pathItem.Path,
 				})
 			}
 		}
 	}
 
-	// Set the results
-	if len(issues) > 0 {
-		results["status"] = "failed"
-		results["issues"] = issues
-	} else {
-		results["status"] = "passed"
-	}
-
-	return results, nil
+	return NewResult(issues), nil
 }
 
 // Name returns the name of the rule
@@ -255,3 +246,14 @@ func (r *SolaceCustomActionsRule) Name() string {
 func (r *SolaceCustomActionsRule) Description() string {
 	return "Validates that custom actions follow Solace conventions"
 }
+
+// Metadata returns the metadata of the rule
+func (r *SolaceCustomActionsRule) Metadata() Metadata {
+	return Metadata{Tags: []string{"methods", "paths"}}
+}
+
+// ADR returns the convention enforced by the rule
+func (r *SolaceCustomActionsRule) ADR() string {
+	return "Custom actions that don't map to a CRUD operation are modelled as sub-resources under " +
+		"/actions/ and invoked with POST (e.g. /api/v2/platform/environments/{id}/actions/restart)."
+}
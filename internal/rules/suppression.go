@@ -0,0 +1,148 @@
+package rules
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/solacedev/restv2-api-server-go/internal/openapi"
+)
+
+// SuppressionExtension is the vendor extension that suppresses the issues
+// of rules within the object carrying it:
+//
+//	x-solace-lint:
+//	  ignore: [api_versioning]
+//	  reason: Legacy v0 path kept for existing clients
+const SuppressionExtension = "x-solace-lint"
+
+// Suppression suppresses the issues of some rules found within an object of
+// the document: the whole document, a path item, an operation or a schema
+type Suppression struct {
+	Rules  []string `json:"ignore"`
+	Reason string   `json:"reason"`
+	// Location is where the suppression is declared
+	Location openapi.Origin `json:"location"`
+}
+
+// FindSuppressions returns the suppressions declared in a document, and
+// problems with declarations that can't be honored
+func FindSuppressions(doc *openapi.Document) ([]Suppression, []string) {
+	var suppressions []Suppression
+	var problems []string
+
+	doc.Walk(func(node map[string]interface{}, _ openapi.Origin) bool {
+		extension, ok := node[SuppressionExtension]
+		if !ok {
+			return true
+		}
+
+		origin := doc.Location(node)
+		suppression, err := parseSuppression(extension)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s at %s: %v", SuppressionExtension, describeOrigin(origin), err))
+			return true
+		}
+		suppression.Location = origin
+		suppressions = append(suppressions, suppression)
+		return true
+	})
+
+	return suppressions, problems
+}
+
+// parseSuppression parses the value of a suppression extension
+func parseSuppression(value interface{}) (Suppression, error) {
+	var suppression Suppression
+
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return suppression, fmt.Errorf("must be an object with ignore and reason")
+	}
+
+	ignore, ok := object["ignore"].([]interface{})
+	if !ok || len(ignore) == 0 {
+		return suppression, fmt.Errorf("ignore must list the rules to suppress")
+	}
+	for _, item := range ignore {
+		ruleName, ok := item.(string)
+		if !ok || ruleName == "" {
+			return suppression, fmt.Errorf("ignore must list rule names")
+		}
+		suppression.Rules = append(suppression.Rules, ruleName)
+	}
+
+	reason, _ := object["reason"].(string)
+	if strings.TrimSpace(reason) == "" {
+		return suppression, fmt.Errorf("reason is required")
+	}
+	suppression.Reason = reason
+
+	return suppression, nil
+}
+
+// describeOrigin describes where a node is defined
+func describeOrigin(origin openapi.Origin) string {
+	location := origin.Pointer
+	if location == "" {
+		location = "/"
+	}
+	if origin.File != "" {
+		location = origin.File + "#" + location
+	}
+	if origin.Line > 0 {
+		location = fmt.Sprintf("%s (line %d)", location, origin.Line)
+	}
+	return location
+}
+
+// covers reports whether the suppression applies to an issue: the issue's
+// rule is ignored and the issue lies within the suppressed object. The
+// suppressions of the document's root object cover the whole document.
+func (s Suppression) covers(doc *openapi.Document, issue Issue) bool {
+	ignored := false
+	for _, ruleName := range s.Rules {
+		if ruleName == issue.Rule {
+			ignored = true
+			break
+		}
+	}
+	if !ignored {
+		return false
+	}
+
+	if s.Location.Pointer == "" && s.Location.File == doc.File {
+		return true
+	}
+	if s.Location.File != issue.Location.File {
+		return false
+	}
+	return issue.Location.Pointer == s.Location.Pointer ||
+		strings.HasPrefix(issue.Location.Pointer, s.Location.Pointer+"/")
+}
+
+// Suppress returns a copy of a result with the issues covered by the
+// suppressions marked as suppressed, and its status updated accordingly
+func Suppress(doc *openapi.Document, result *Result, suppressions []Suppression) *Result {
+	if len(suppressions) == 0 || len(result.Issues) == 0 {
+		return result
+	}
+	if result.Status != StatusPassed && result.Status != StatusFailed {
+		return result
+	}
+
+	issues := make([]Issue, len(result.Issues))
+	for i, issue := range result.Issues {
+		for j := range suppressions {
+			if suppressions[j].covers(doc, issue) {
+				suppression := suppressions[j]
+				issue.Suppressed = true
+				issue.Suppression = &suppression
+				break
+			}
+		}
+		issues[i] = issue
+	}
+	suppressed := NewResult(issues)
+	suppressed.Message = result.Message
+	return suppressed
+}
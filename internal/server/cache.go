@@ -0,0 +1,174 @@
+package server
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+
+	"github.com/solacedev/restv2-api-server-go/internal/openapi"
+	"github.com/solacedev/restv2-api-server-go/internal/rules"
+	"github.com/solacedev/restv2-api-server-go/internal/validator"
+)
+
+// resultCacheSize bounds the number of validation results kept in the cache
+const resultCacheSize = 64
+
+// resultCache is an LRU cache of validation results. Results are keyed by
+// the normalized content of the spec and the versions of the rules applied,
+// and are discarded as a whole when the set of rules changes.
+type resultCache struct {
+	mu         sync.Mutex
+	size       int
+	generation uint64
+	entries    map[string]*list.Element
+	order      *list.List
+}
+
+// cacheEntry is a cached validation result
+type cacheEntry struct {
+	key      string
+	response map[string]interface{}
+}
+
+// newResultCache creates a result cache holding up to size results
+func newResultCache(size int) *resultCache {
+	return &resultCache{
+		size:    size,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// sync discards the cached results when they were computed with another
+// generation of rules. The caller must hold the lock.
+func (c *resultCache) sync(generation uint64) {
+	if generation != c.generation {
+		c.entries = make(map[string]*list.Element)
+		c.order.Init()
+		c.generation = generation
+	}
+}
+
+// get returns the cached result for a key, if any
+func (c *resultCache) get(key string, generation uint64) (map[string]interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.sync(generation)
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(element)
+	return element.Value.(*cacheEntry).response, true
+}
+
+// put caches a result, evicting the least recently used one when full
+func (c *resultCache) put(key string, generation uint64, response map[string]interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.sync(generation)
+	if element, ok := c.entries[key]; ok {
+		element.Value.(*cacheEntry).response = response
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&cacheEntry{key: key, response: response})
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// cacheKey returns the cache key of validating a document with a rule set.
+// The file is part of the key since issue locations refer to it.
+func cacheKey(doc *openapi.Document, ruleSetVersion string) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%s\x00%s", doc.File, doc.Digest, ruleSetVersion)))
+	return hex.EncodeToString(sum[:])
+}
+
+// validate validates the API spec of a validate request, reusing the cached
+// result of an identical validation when there is one. The response says
+// whether it came from the cache.
+func (s *Server) validate(ctx context.Context, params map[string]interface{}, onResult validator.ResultHandler) (map[string]interface{}, error) {
+	doc, err := s.validator.ParseParams(params)
+	if err != nil {
+		return nil, err
+	}
+
+	selection, err := s.validator.SelectRules(params)
+	if err != nil {
+		return nil, err
+	}
+	ruleNames := selection.Rules
+	key := cacheKey(doc, s.validator.RuleSetVersion(selection))
+	generation := s.validator.Generation()
+
+	if cached, ok := s.cache.get(key, generation); ok {
+		response := relocate(cached, doc)
+		if onResult != nil {
+			results := response["results"].(map[string]*rules.Result)
+			for i, name := range ruleNames {
+				onResult(name, results[name], i+1, len(ruleNames))
+			}
+		}
+		response["cached"] = true
+		return response, nil
+	}
+
+	response, err := s.validator.ValidateDocument(ctx, doc, selection, onResult)
+	if err != nil {
+		return nil, err
+	}
+
+	// Rules that errored may have timed out, so such results aren't reused
+	if summary, ok := response["summary"].(rules.Summary); !ok || summary.Status != rules.StatusError {
+		s.cache.put(key, generation, response)
+	}
+
+	copied := make(map[string]interface{}, len(response)+1)
+	for k, v := range response {
+		copied[k] = v
+	}
+	copied["cached"] = false
+	return copied, nil
+}
+
+// relocate copies a cached response, updating the line and column of each
+// issue from the document being validated. Its content matches the cached
+// one but its formatting, and so the positions, may differ.
+func relocate(cached map[string]interface{}, doc *openapi.Document) map[string]interface{} {
+	response := make(map[string]interface{}, len(cached)+1)
+	for k, v := range cached {
+		response[k] = v
+	}
+
+	cachedResults, _ := cached["results"].(map[string]*rules.Result)
+	results := make(map[string]*rules.Result, len(cachedResults))
+	for name, cachedResult := range cachedResults {
+		result := *cachedResult
+		if len(cachedResult.Issues) > 0 {
+			result.Issues = make([]rules.Issue, len(cachedResult.Issues))
+			for i, issue := range cachedResult.Issues {
+				issue.Location = doc.Locate(issue.Location.File, issue.Location.Pointer)
+				if issue.Suppression != nil {
+					suppression := *issue.Suppression
+					suppression.Location = doc.Locate(suppression.Location.File, suppression.Location.Pointer)
+					issue.Suppression = &suppression
+				}
+				result.Issues[i] = issue
+			}
+			rules.SortIssues(result.Issues)
+		}
+		results[name] = &result
+	}
+	response["results"] = results
+
+	return response
+}
@@ -0,0 +1,166 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// JSON-RPC 2.0 error codes
+const (
+	ParseError     = -32700
+	InvalidRequest = -32600
+	MethodNotFound = -32601
+	InvalidParams  = -32602
+	InternalError  = -32603
+
+	// ResourceNotFound is the MCP error code for unknown resource URIs
+	ResourceNotFound = -32002
+)
+
+// jsonrpcVersion is the only JSON-RPC version the server speaks
+const jsonrpcVersion = "2.0"
+
+// Request is a JSON-RPC 2.0 request or notification
+type Request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// IsNotification reports whether the request is a notification, i.e. has
+// no id and expects no response
+func (r *Request) IsNotification() bool {
+	return r.ID == nil
+}
+
+// params decodes the request params as an object. Missing params decode to
+// an empty map.
+func (r *Request) params() (map[string]interface{}, error) {
+	params := make(map[string]interface{})
+	if len(r.Params) == 0 || bytes.Equal(r.Params, []byte("null")) {
+		return params, nil
+	}
+	if err := json.Unmarshal(r.Params, &params); err != nil {
+		return nil, newError(InvalidParams, "params must be an object")
+	}
+	return params, nil
+}
+
+// Response is a JSON-RPC 2.0 response
+type Response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+// Error is a JSON-RPC 2.0 error object
+type Error struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+// Error implements the error interface
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// newError creates a JSON-RPC error with a formatted message
+func newError(code int, format string, args ...interface{}) *Error {
+	return &Error{
+		Code:    code,
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+// toError converts an error returned by a handler into a JSON-RPC error.
+// Errors that don't carry a code are reported as internal errors.
+func toError(err error) *Error {
+	if rpcErr, ok := err.(*Error); ok {
+		return rpcErr
+	}
+	return &Error{
+		Code:    InternalError,
+		Message: err.Error(),
+	}
+}
+
+// nullID is the id of responses to requests whose id couldn't be determined
+var nullID = json.RawMessage("null")
+
+// newResult creates a successful response
+func newResult(id json.RawMessage, result interface{}) *Response {
+	if result == nil {
+		result = map[string]interface{}{}
+	}
+	return &Response{
+		JSONRPC: jsonrpcVersion,
+		ID:      id,
+		Result:  result,
+	}
+}
+
+// newErrorResponse creates an error response
+func newErrorResponse(id json.RawMessage, err *Error) *Response {
+	if id == nil {
+		id = nullID
+	}
+	return &Response{
+		JSONRPC: jsonrpcVersion,
+		ID:      id,
+		Error:   err,
+	}
+}
+
+// decodeMessage splits a JSON-RPC message into its requests. A message is
+// either a single request object or a batch array of them.
+func decodeMessage(data []byte) ([]json.RawMessage, bool, error) {
+	data = bytes.TrimSpace(data)
+	if !json.Valid(data) {
+		return nil, false, fmt.Errorf("invalid JSON")
+	}
+
+	if len(data) > 0 && data[0] == '[' {
+		var batch []json.RawMessage
+		if err := json.Unmarshal(data, &batch); err != nil {
+			return nil, false, err
+		}
+		return batch, true, nil
+	}
+
+	return []json.RawMessage{data}, false, nil
+}
+
+// decodeRequest decodes and checks a single JSON-RPC request
+func decodeRequest(raw json.RawMessage) (*Request, *Error) {
+	var request Request
+	if err := json.Unmarshal(raw, &request); err != nil {
+		return nil, newError(InvalidRequest, "invalid request: must be a JSON-RPC request object")
+	}
+	if request.JSONRPC != jsonrpcVersion {
+		return &request, newError(InvalidRequest, "invalid request: jsonrpc must be %q", jsonrpcVersion)
+	}
+	if request.ID != nil && !validID(request.ID) {
+		return &request, newError(InvalidRequest, "invalid request: id must be a string, number or null")
+	}
+	if request.Method == "" {
+		return &request, newError(InvalidRequest, "invalid request: missing method")
+	}
+	return &request, nil
+}
+
+// validID reports whether a raw id is a string, number or null
+func validID(id json.RawMessage) bool {
+	var value interface{}
+	if err := json.Unmarshal(id, &value); err != nil {
+		return false
+	}
+	switch value.(type) {
+	case nil, string, float64:
+		return true
+	}
+	return false
+}
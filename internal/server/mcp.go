@@ -0,0 +1,304 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/solacedev/restv2-api-server-go/internal/rules"
+)
+
+const (
+	// serverName is the name reported to MCP clients during initialization
+	serverName = "restv2-api-server-go"
+	// serverVersion is the version reported to MCP clients during initialization
+	serverVersion = "1.0.0"
+	// latestProtocolVersion is the newest MCP protocol revision the server speaks
+	latestProtocolVersion = "2025-06-18"
+)
+
+// supportedProtocolVersions lists the MCP protocol revisions the server
+// accepts, newest first
+var supportedProtocolVersions = []string{
+	latestProtocolVersion,
+	"2025-03-26",
+	"2024-11-05",
+}
+
+// isSupportedProtocolVersion reports whether the given MCP protocol revision is supported
+func isSupportedProtocolVersion(version string) bool {
+	for _, v := range supportedProtocolVersions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
+
+// handleInitialize handles the MCP initialize request and negotiates the
+// protocol version and capabilities with the client
+func (s *Server) handleInitialize(sess *session, params map[string]interface{}) (map[string]interface{}, error) {
+	requested, ok := params["protocolVersion"].(string)
+	if !ok || requested == "" {
+		return nil, newError(InvalidParams, "missing or invalid protocolVersion parameter")
+	}
+
+	// Answer with the requested version when supported, otherwise propose our latest
+	version := requested
+	if !isSupportedProtocolVersion(requested) {
+		version = latestProtocolVersion
+	}
+
+	clientInfo, _ := params["clientInfo"].(map[string]interface{})
+	log.Printf("Initializing MCP session with client %v (requested protocol %s, using %s)", clientInfo["name"], requested, version)
+
+	sess.mu.Lock()
+	sess.protocolVersion = version
+	sess.initialized = false
+	sess.mu.Unlock()
+
+	return map[string]interface{}{
+		"protocolVersion": version,
+		"capabilities":    s.capabilities(),
+		"serverInfo": map[string]interface{}{
+			"name":    serverName,
+			"version": serverVersion,
+		},
+		"instructions": "Validates REST API designs and OpenAPI specifications against the Solace REST API conventions (ADRs).",
+	}, nil
+}
+
+// handleInitialized handles the notifications/initialized notification
+func (s *Server) handleInitialized(sess *session) {
+	sess.mu.Lock()
+	sess.initialized = true
+	sess.mu.Unlock()
+}
+
+// capabilities returns the capabilities advertised to MCP clients
+func (s *Server) capabilities() map[string]interface{} {
+	return map[string]interface{}{
+		"tools": map[string]interface{}{
+			"listChanged": false,
+		},
+		"resources": map[string]interface{}{
+			"subscribe":   false,
+			"listChanged": true,
+		},
+		"prompts": map[string]interface{}{
+			"listChanged": false,
+		},
+		"logging": map[string]interface{}{},
+	}
+}
+
+// toolDefinitions returns the MCP definitions of the tools exposed by the server
+func (s *Server) toolDefinitions() []map[string]interface{} {
+	return []map[string]interface{}{
+		{
+			"name":        "validate_api",
+			"description": "Validate a REST API against a set of rules",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"api_spec": map[string]interface{}{
+						"type":        "string",
+						"description": "OpenAPI specification in YAML or JSON format",
+					},
+					"rules": map[string]interface{}{
+						"type":        "array",
+						"description": "List of rules to validate against",
+						"items": map[string]interface{}{
+							"type": "string",
+						},
+					},
+					"profile": map[string]interface{}{
+						"type":        "string",
+						"description": "Rule profile to validate against, e.g. solace-public, solace-internal or minimal",
+					},
+					"tags": map[string]interface{}{
+						"type":        "array",
+						"description": "Only validate against rules with any of these tags, e.g. naming, pagination or errors",
+						"items": map[string]interface{}{
+							"type": "string",
+						},
+					},
+				},
+				"required": []string{"api_spec"},
+			},
+		},
+		{
+			"name":        "list_rules",
+			"description": "List the available rules with their ADR, tags and metadata",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"profile": map[string]interface{}{
+						"type":        "string",
+						"description": "Only list the rules of this profile",
+					},
+					"tags": map[string]interface{}{
+						"type":        "array",
+						"description": "Only list rules with any of these tags",
+						"items": map[string]interface{}{
+							"type": "string",
+						},
+					},
+				},
+			},
+		},
+		{
+			"name":        "validate_url_path",
+			"description": "Validate a single URL path against Solace REST API conventions",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"url_path": map[string]interface{}{
+						"type":        "string",
+						"description": "URL path to validate (e.g., /api/v0/admin/cloudAgents/{datacenterId}/upgrades)",
+					},
+				},
+				"required": []string{"url_path"},
+			},
+		},
+	}
+}
+
+// handleToolsList handles the MCP tools/list request
+func (s *Server) handleToolsList() map[string]interface{} {
+	return map[string]interface{}{
+		"tools": s.toolDefinitions(),
+	}
+}
+
+// handleToolsCall handles the MCP tools/call request. Partial validation
+// results are streamed to the client as log messages while rules run, and
+// progress is reported when the client sent a progress token.
+func (s *Server) handleToolsCall(ctx context.Context, sess *session, params map[string]interface{}, notify notifier) (map[string]interface{}, error) {
+	name, ok := params["name"].(string)
+	if !ok || name == "" {
+		return nil, newError(InvalidParams, "missing or invalid tool name")
+	}
+
+	arguments, ok := params["arguments"].(map[string]interface{})
+	if !ok {
+		arguments = make(map[string]interface{})
+	}
+
+	var result map[string]interface{}
+	var err error
+
+	switch name {
+	case "validate_api":
+		progressToken := progressToken(params)
+		result, err = s.validate(ctx, arguments, func(ruleName string, ruleResult *rules.Result, completed, total int) {
+			if progressToken != nil {
+				notify(map[string]interface{}{
+					"jsonrpc": "2.0",
+					"method":  "notifications/progress",
+					"params": map[string]interface{}{
+						"progressToken": progressToken,
+						"progress":      completed,
+						"total":         total,
+						"message":       fmt.Sprintf("Applied rule %s", ruleName),
+					},
+				})
+			}
+
+			// The full result of each rule is only sent at debug level, since
+			// the final response repeats it
+			data := map[string]interface{}{"rule": ruleName}
+			level := "debug"
+			switch {
+			case sess.logs("debug"):
+				data["result"] = ruleResult
+			case sess.logs("info"):
+				level = "info"
+				data["summary"] = rules.Summarize(map[string]*rules.Result{ruleName: ruleResult})
+			default:
+				return
+			}
+			notify(map[string]interface{}{
+				"jsonrpc": "2.0",
+				"method":  "notifications/message",
+				"params": map[string]interface{}{
+					"level":  level,
+					"logger": "validate_api",
+					"data":   data,
+				},
+			})
+		})
+	case "validate_url_path":
+		result, err = s.validator.ValidateURLPath(ctx, arguments)
+	case "list_rules":
+		result, err = s.validator.ListRules(arguments)
+	default:
+		return nil, newError(InvalidParams, "unknown tool: %s", name)
+	}
+
+	// Tool execution errors are reported in the result so the model can see them
+	if err != nil {
+		return map[string]interface{}{
+			"content": []map[string]interface{}{
+				{
+					"type": "text",
+					"text": err.Error(),
+				},
+			},
+			"isError": true,
+		}, nil
+	}
+
+	text, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("error encoding tool result: %v", err)
+	}
+
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": string(text),
+			},
+		},
+		"structuredContent": result,
+		"isError":           false,
+	}, nil
+}
+
+// progressToken returns the progress token of a request, if the client sent one
+func progressToken(params map[string]interface{}) interface{} {
+	meta, ok := params["_meta"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	return meta["progressToken"]
+}
+
+// logLevels lists the MCP log levels from least to most severe
+var logLevels = []string{"debug", "info", "notice", "warning", "error", "critical", "alert", "emergency"}
+
+// logLevelIndex returns the position of a log level in logLevels, or -1
+func logLevelIndex(level string) int {
+	for i, l := range logLevels {
+		if l == level {
+			return i
+		}
+	}
+	return -1
+}
+
+// handleSetLevel handles the MCP logging/setLevel request
+func (s *Server) handleSetLevel(sess *session, params map[string]interface{}) (map[string]interface{}, error) {
+	level, ok := params["level"].(string)
+	if !ok || logLevelIndex(level) < 0 {
+		return nil, newError(InvalidParams, "missing or invalid level parameter")
+	}
+
+	sess.mu.Lock()
+	sess.logLevel = level
+	sess.mu.Unlock()
+
+	return map[string]interface{}{}, nil
+}
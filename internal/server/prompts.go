@@ -0,0 +1,214 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/solacedev/restv2-api-server-go/internal/rules"
+)
+
+// promptArgument describes an argument accepted by a prompt template
+type promptArgument struct {
+	Name        string
+	Description string
+	Required    bool
+}
+
+// promptTemplate describes a convention-guided design prompt. The guidance
+// embedded in the prompt is generated from the loaded rules it selects, so it
+// always matches what the validator enforces.
+type promptTemplate struct {
+	Name        string
+	Title       string
+	Description string
+	Arguments   []promptArgument
+	// Tags select the loaded rules whose ADR text is included, grouped by
+	// tag in order
+	Tags []string
+	// Task renders the request to the assistant from the prompt arguments
+	Task func(args map[string]string) string
+}
+
+// promptTemplates returns the prompt templates exposed by the server
+func promptTemplates() []promptTemplate {
+	return []promptTemplate{
+		{
+			Name:        "design_collection_resource",
+			Title:       "Design a collection resource",
+			Description: "Design a new collection resource and its endpoints following the Solace REST API conventions",
+			Arguments: []promptArgument{
+				{Name: "resource", Description: "Name of the resource type to design (e.g. environments)", Required: true},
+				{Name: "product_area", Description: "Product area the resource belongs to (e.g. platform)"},
+				{Name: "fields", Description: "Fields the resource should expose"},
+			},
+			Tags: []string{"paths", "naming", "methods", "payload", "fields", "pagination", "errors"},
+			Task: func(args map[string]string) string {
+				area := args["product_area"]
+				if area == "" {
+					area = "{product area}"
+				}
+				task := fmt.Sprintf("Design the REST API for a new `%s` collection resource under `/api/v2/%s/`. "+
+					"Provide the collection and single-resource paths, the operations on each, the request and response "+
+					"payload schemas and the error responses, as an OpenAPI 3.0 snippet.", args["resource"], area)
+				if args["fields"] != "" {
+					task += fmt.Sprintf(" The resource has the following fields: %s.", args["fields"])
+				}
+				return task
+			},
+		},
+		{
+			Name:        "add_pagination",
+			Title:       "Add pagination to an endpoint",
+			Description: "Add pagination to an existing collection GET endpoint following the Solace REST API conventions",
+			Arguments: []promptArgument{
+				{Name: "endpoint", Description: "Path of the collection endpoint to paginate (e.g. /api/v2/platform/environments)", Required: true},
+				{Name: "api_spec", Description: "Current OpenAPI definition of the endpoint"},
+			},
+			Tags: []string{"pagination", "payload", "query"},
+			Task: func(args map[string]string) string {
+				task := fmt.Sprintf("Add pagination to the `GET %s` collection endpoint. "+
+					"Provide the query parameters and the updated response schema as an OpenAPI 3.0 snippet.", args["endpoint"])
+				if args["api_spec"] != "" {
+					task += "\n\nThe current definition is:\n\n```yaml\n" + args["api_spec"] + "\n```"
+				}
+				return task
+			},
+		},
+		{
+			Name:        "make_long_running",
+			Title:       "Make an operation long-running",
+			Description: "Turn a synchronous operation into a long-running operation following the Solace REST API conventions",
+			Arguments: []promptArgument{
+				{Name: "operation", Description: "Method and path of the operation (e.g. POST /api/v2/infrastructure/services)", Required: true},
+				{Name: "api_spec", Description: "Current OpenAPI definition of the operation"},
+			},
+			Tags: []string{"async", "payload", "errors", "fields"},
+			Task: func(args map[string]string) string {
+				task := fmt.Sprintf("Make the `%s` operation long-running. Provide the updated operation, the Operation "+
+					"sub-resource endpoints and the Operation schema as an OpenAPI 3.0 snippet.", args["operation"])
+				if args["api_spec"] != "" {
+					task += "\n\nThe current definition is:\n\n```yaml\n" + args["api_spec"] + "\n```"
+				}
+				return task
+			},
+		},
+	}
+}
+
+// findPromptTemplate returns the prompt template with the given name
+func findPromptTemplate(name string) (promptTemplate, bool) {
+	for _, prompt := range promptTemplates() {
+		if prompt.Name == name {
+			return prompt, true
+		}
+	}
+	return promptTemplate{}, false
+}
+
+// handlePromptsList handles the MCP prompts/list request
+func (s *Server) handlePromptsList() map[string]interface{} {
+	prompts := []map[string]interface{}{}
+	for _, prompt := range promptTemplates() {
+		arguments := []map[string]interface{}{}
+		for _, arg := range prompt.Arguments {
+			arguments = append(arguments, map[string]interface{}{
+				"name":        arg.Name,
+				"description": arg.Description,
+				"required":    arg.Required,
+			})
+		}
+
+		prompts = append(prompts, map[string]interface{}{
+			"name":        prompt.Name,
+			"title":       prompt.Title,
+			"description": prompt.Description,
+			"arguments":   arguments,
+		})
+	}
+
+	return map[string]interface{}{
+		"prompts": prompts,
+	}
+}
+
+// handlePromptsGet handles the MCP prompts/get request
+func (s *Server) handlePromptsGet(params map[string]interface{}) (map[string]interface{}, error) {
+	name, ok := params["name"].(string)
+	if !ok || name == "" {
+		return nil, newError(InvalidParams, "missing or invalid prompt name")
+	}
+
+	prompt, ok := findPromptTemplate(name)
+	if !ok {
+		return nil, newError(InvalidParams, "unknown prompt: %s", name)
+	}
+
+	// Prompt arguments are always strings
+	args := make(map[string]string)
+	if rawArgs, ok := params["arguments"].(map[string]interface{}); ok {
+		for key, value := range rawArgs {
+			if str, ok := value.(string); ok {
+				args[key] = str
+			}
+		}
+	}
+
+	for _, arg := range prompt.Arguments {
+		if arg.Required && args[arg.Name] == "" {
+			return nil, newError(InvalidParams, "missing required argument: %s", arg.Name)
+		}
+	}
+
+	return map[string]interface{}{
+		"description": prompt.Description,
+		"messages": []map[string]interface{}{
+			{
+				"role": "user",
+				"content": map[string]interface{}{
+					"type": "text",
+					"text": s.renderPrompt(prompt, args),
+				},
+			},
+		},
+	}, nil
+}
+
+// renderPrompt renders a prompt template with the guidance of the loaded
+// rules it selects. Disabled rules are left out.
+func (s *Server) renderPrompt(prompt promptTemplate, args map[string]string) string {
+	var b strings.Builder
+	b.WriteString(prompt.Task(args))
+	b.WriteString("\n\nThe design MUST follow the Solace REST API conventions below. ")
+	b.WriteString("They are the conventions enforced by the validate_api tool, so validate the result with it before presenting it.\n")
+
+	for _, rule := range s.promptRules(prompt) {
+		if enabled, ok := rules.Describe(rule)["enabled"].(bool); ok && !enabled {
+			continue
+		}
+
+		fmt.Fprintf(&b, "\n## %s\n\n%s\n\n", rule.Name(), rule.Description())
+		b.WriteString(strings.TrimSpace(rules.ADRText(rule)))
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+// promptRules returns the loaded rules having any of the tags of a prompt
+// template, grouped by tag in order and sorted by name within a tag
+func (s *Server) promptRules(prompt promptTemplate) []rules.Rule {
+	var selected []rules.Rule
+	seen := make(map[string]bool)
+	names := s.validator.GetRules()
+	for _, tag := range prompt.Tags {
+		for _, name := range names {
+			rule, ok := s.validator.GetRule(name)
+			if !ok || seen[name] || !rules.HasTag(rule, tag) {
+				continue
+			}
+			seen[name] = true
+			selected = append(selected, rule)
+		}
+	}
+	return selected
+}
@@ -0,0 +1,112 @@
+package server
+
+import (
+	"log"
+	"time"
+)
+
+// rulesReloadInterval is how often the rules directory is checked for changes
+const rulesReloadInterval = 2 * time.Second
+
+// watchRules starts the rules watcher, once per server. Without rules
+// directories only the embedded rules are loaded, which never change.
+func (s *Server) watchRules() {
+	if len(s.validator.RulesDirs()) == 0 {
+		return
+	}
+	s.watchOnce.Do(func() {
+		go s.rulesWatcher()
+	})
+}
+
+// rulesWatcher periodically reloads the JSON rules. Clients are notified
+// when the rule set changes; a rule file that can't be loaded leaves the
+// current rules in place and is reported once until it's fixed.
+func (s *Server) rulesWatcher() {
+	ticker := time.NewTicker(rulesReloadInterval)
+	defer ticker.Stop()
+
+	lastError := ""
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+			changed, err := s.validator.ReloadRules()
+			if err != nil {
+				if err.Error() != lastError {
+					lastError = err.Error()
+					log.Printf("Error reloading rules, keeping the current rules: %v", err)
+					s.broadcastLog("error", "rules", map[string]interface{}{
+						"message": "Error reloading rules, keeping the current rules",
+						"error":   lastError,
+					})
+				}
+				continue
+			}
+			if lastError != "" {
+				lastError = ""
+				log.Println("Rules loaded successfully again")
+			}
+
+			if changed {
+				log.Printf("Rules reloaded, %d rules available", len(s.validator.GetRules()))
+				s.broadcast(map[string]interface{}{
+					"jsonrpc": "2.0",
+					"method":  "notifications/resources/list_changed",
+				})
+			}
+		}
+	}
+}
+
+// liveSessions returns the initialized sessions of all transports
+func (s *Server) liveSessions() []*session {
+	s.sessionsMu.Lock()
+	defer s.sessionsMu.Unlock()
+
+	var sessions []*session
+	for _, sess := range s.sessions {
+		sessions = append(sessions, sess)
+	}
+	for sess := range s.stdioSessions {
+		sessions = append(sessions, sess)
+	}
+
+	live := sessions[:0]
+	for _, sess := range sessions {
+		sess.mu.Lock()
+		initialized := sess.initialized
+		sess.mu.Unlock()
+		if initialized {
+			live = append(live, sess)
+		}
+	}
+	return live
+}
+
+// broadcast sends a notification to every initialized session
+func (s *Server) broadcast(message map[string]interface{}) {
+	for _, sess := range s.liveSessions() {
+		sess.notify(message)
+	}
+}
+
+// broadcastLog sends a log message to every initialized session whose log
+// level lets it through
+func (s *Server) broadcastLog(level, logger string, data interface{}) {
+	for _, sess := range s.liveSessions() {
+		if !sess.logs(level) {
+			continue
+		}
+		sess.notify(map[string]interface{}{
+			"jsonrpc": "2.0",
+			"method":  "notifications/message",
+			"params": map[string]interface{}{
+				"level":  level,
+				"logger": logger,
+				"data":   data,
+			},
+		})
+	}
+}
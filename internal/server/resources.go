@@ -0,0 +1,77 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/solacedev/restv2-api-server-go/internal/rules"
+)
+
+// ruleURIScheme is the URI scheme under which rules are published as resources
+const ruleURIScheme = "rule://"
+
+// ruleURI returns the resource URI of the named rule
+func ruleURI(name string) string {
+	return ruleURIScheme + name
+}
+
+// handleResourcesList handles the MCP resources/list request
+func (s *Server) handleResourcesList() map[string]interface{} {
+	resources := []map[string]interface{}{}
+	for _, name := range s.validator.GetRules() {
+		rule, ok := s.validator.GetRule(name)
+		if !ok {
+			continue
+		}
+
+		resources = append(resources, map[string]interface{}{
+			"uri":         ruleURI(name),
+			"name":        name,
+			"description": rule.Description(),
+			"mimeType":    "text/markdown",
+		})
+	}
+
+	return map[string]interface{}{
+		"resources": resources,
+	}
+}
+
+// handleResourcesRead handles the MCP resources/read request. A rule is
+// returned both as a markdown document and as its structured catalog entry.
+func (s *Server) handleResourcesRead(params map[string]interface{}) (map[string]interface{}, error) {
+	uri, ok := params["uri"].(string)
+	if !ok || uri == "" {
+		return nil, newError(InvalidParams, "missing or invalid uri parameter")
+	}
+
+	if !strings.HasPrefix(uri, ruleURIScheme) {
+		return nil, newError(ResourceNotFound, "resource not found: %s", uri)
+	}
+
+	rule, ok := s.validator.GetRule(strings.TrimPrefix(uri, ruleURIScheme))
+	if !ok {
+		return nil, newError(ResourceNotFound, "resource not found: %s", uri)
+	}
+
+	entry, err := json.MarshalIndent(rules.Describe(rule), "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("error encoding rule %s: %v", rule.Name(), err)
+	}
+
+	return map[string]interface{}{
+		"contents": []map[string]interface{}{
+			{
+				"uri":      uri,
+				"mimeType": "text/markdown",
+				"text":     rules.Markdown(rule),
+			},
+			{
+				"uri":      uri,
+				"mimeType": "application/json",
+				"text":     string(entry),
+			},
+		},
+	}, nil
+}
@@ -1,11 +1,14 @@
 package server
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
+	"strings"
 	"sync"
 	"time"
 
@@ -18,22 +21,38 @@ type Server struct {
 	keepAlive bool
 	server    *http.Server
 	validator *validator.Validator
+	cache     *resultCache
 	mu        sync.Mutex
 	lastPing  time.Time
+
+	// HTTP sessions keyed by Mcp-Session-Id, and the sessions of the stdio
+	// transports being served
+	sessionsMu    sync.Mutex
+	sessions      map[string]*session
+	stdioSessions map[*session]bool
+	done          chan struct{}
+	closeOnce     sync.Once
+	watchOnce     sync.Once
 }
 
-// NewServer creates a new server instance
-func NewServer(port int, keepAlive bool) (*Server, error) {
-	v, err := validator.NewValidator()
+// NewServer creates a new server instance. JSON rules are loaded from the
+// given directories on top of the embedded defaults, later directories
+// taking precedence.
+func NewServer(port int, keepAlive bool, rulesDirs []string) (*Server, error) {
+	v, err := validator.NewValidator(rulesDirs...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create validator: %v", err)
 	}
 
 	s := &Server{
-		port:      port,
-		keepAlive: keepAlive,
-		validator: v,
-		lastPing:  time.Now(),
+		port:          port,
+		keepAlive:     keepAlive,
+		validator:     v,
+		cache:         newResultCache(resultCacheSize),
+		lastPing:      time.Now(),
+		sessions:      make(map[string]*session),
+		stdioSessions: make(map[*session]bool),
+		done:          make(chan struct{}),
 	}
 
 	mux := http.NewServeMux()
@@ -54,166 +73,268 @@ func (s *Server) Start() error {
 		go s.keepAliveMonitor()
 	}
 
+	// Expire idle sessions
+	go s.sessionReaper()
+
+	// Reload rules when they change
+	s.watchRules()
+
 	// Start the HTTP server
 	return s.server.ListenAndServe()
 }
 
+// ServeStdio serves MCP requests over the given reader and writer until the
+// reader is closed. The keep-alive monitor is not used on this transport.
+func (s *Server) ServeStdio(in io.Reader, out io.Writer) error {
+	t := NewStdioTransport(s, in, out)
+
+	// Register the session so it's notified of rule changes
+	s.sessionsMu.Lock()
+	s.stdioSessions[t.session] = true
+	s.sessionsMu.Unlock()
+	defer func() {
+		s.sessionsMu.Lock()
+		delete(s.stdioSessions, t.session)
+		s.sessionsMu.Unlock()
+	}()
+
+	s.watchRules()
+	return t.Serve()
+}
+
 // Shutdown gracefully shuts down the server
 func (s *Server) Shutdown() error {
+	// Release open event streams so the HTTP server can drain
+	s.closeOnce.Do(func() { close(s.done) })
+
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	return s.server.Shutdown(ctx)
 }
 
-// handleMCP handles MCP requests
+// handleMCP handles MCP requests on the Streamable HTTP transport
 func (s *Server) handleMCP(w http.ResponseWriter, r *http.Request) {
-	// Only accept POST requests
-	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	// Reject protocol revisions we don't speak
+	if version := r.Header.Get("MCP-Protocol-Version"); version != "" && !isSupportedProtocolVersion(version) {
+		http.Error(w, fmt.Sprintf("Unsupported MCP protocol version: %s", version), http.StatusBadRequest)
 		return
 	}
 
-	// Parse the request
-	var request map[string]interface{}
-	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
-		http.Error(w, fmt.Sprintf("Error parsing request: %v", err), http.StatusBadRequest)
-		return
+	switch r.Method {
+	case http.MethodPost:
+		s.handlePost(w, r)
+	case http.MethodGet:
+		s.handleEventStream(w, r)
+	case http.MethodDelete:
+		s.handleDeleteSession(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 	}
+}
 
-	// Check if this is a ping request
-	if method, ok := request["method"].(string); ok && method == "ping" {
-		s.handlePing(w)
-		return
+// handleMessage handles a raw JSON-RPC message, either a single request or
+// a batch, and returns the response to send back: a *Response, a batch of
+// them, or nil when there is nothing to answer (notifications only). It is
+// shared by the HTTP and stdio transports.
+func (s *Server) handleMessage(ctx context.Context, sess *session, data []byte, notify notifier) interface{} {
+	raws, batch, err := decodeMessage(data)
+	if err != nil {
+		return newErrorResponse(nil, newError(ParseError, "parse error: %v", err))
 	}
 
-	// Process the request
-	response, err := s.processRequest(request)
-	if err != nil {
-		errorResponse := map[string]interface{}{
-			"jsonrpc": "2.0",
-			"id":      request["id"],
-			"error": map[string]interface{}{
-				"code":    -32000,
-				"message": fmt.Sprintf("Error processing request: %v", err),
-			},
+	if !batch {
+		if response := s.handleRaw(ctx, sess, raws[0], notify); response != nil {
+			return response
 		}
-		json.NewEncoder(w).Encode(errorResponse)
-		return
+		return nil
 	}
 
-	// Send the response
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	if len(raws) == 0 {
+		return newErrorResponse(nil, newError(InvalidRequest, "invalid request: empty batch"))
+	}
+
+	responses := []*Response{}
+	for _, raw := range raws {
+		if response := s.handleRaw(ctx, sess, raw, notify); response != nil {
+			responses = append(responses, response)
+		}
+	}
+	if len(responses) == 0 {
+		return nil
+	}
+	return responses
+}
+
+// handleRaw decodes and handles a single request of a message
+func (s *Server) handleRaw(ctx context.Context, sess *session, raw json.RawMessage, notify notifier) *Response {
+	request, rpcErr := decodeRequest(raw)
+	if rpcErr != nil {
+		id := nullID
+		if request != nil && request.ID != nil && validID(request.ID) {
+			id = request.ID
+		}
+		return newErrorResponse(id, rpcErr)
+	}
+	return s.handleRequest(ctx, sess, request, notify)
+}
+
+// handleRequest dispatches a decoded request and builds its response.
+// Notifications produce no response and nil is returned for them. Messages
+// the server sends while handling the request are delivered through notify.
+// Requests cancelled by the client aren't answered either.
+func (s *Server) handleRequest(ctx context.Context, sess *session, request *Request, notify notifier) *Response {
+	if request.IsNotification() {
+		s.handleNotification(ctx, sess, request, notify)
+		return nil
+	}
+
+	// Track the request so the client can cancel it
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	sess.track(request.ID, cancel)
+	defer sess.untrack(request.ID)
+
+	result, err := s.processRequest(ctx, sess, request, notify)
+	if ctx.Err() != nil {
+		log.Printf("Request %s (%s) was cancelled", request.ID, request.Method)
+		return nil
+	}
+	if err != nil {
+		return newErrorResponse(request.ID, toError(err))
+	}
+
+	return newResult(request.ID, result)
 }
 
 // handlePing handles ping requests
-func (s *Server) handlePing(w http.ResponseWriter) {
+func (s *Server) handlePing() interface{} {
 	// Update last ping time
 	s.mu.Lock()
 	s.lastPing = time.Now()
 	s.mu.Unlock()
 
-	// Send pong response
-	response := map[string]interface{}{
-		"jsonrpc": "2.0",
-		"id":      1,
-		"result":  "pong",
-	}
+	// The MCP specification requires an empty result
+	return map[string]interface{}{}
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+// handleNotification handles a JSON-RPC notification from the client.
+// Notifications for regular methods are processed but never answered.
+func (s *Server) handleNotification(ctx context.Context, sess *session, request *Request, notify notifier) {
+	switch {
+	case request.Method == "notifications/initialized":
+		s.handleInitialized(sess)
+	case request.Method == "notifications/cancelled":
+		s.handleCancelled(sess, request)
+	case strings.HasPrefix(request.Method, "notifications/"):
+		log.Printf("Ignoring notification: %s", request.Method)
+	default:
+		if _, err := s.processRequest(ctx, sess, request, notify); err != nil {
+			log.Printf("Error processing notification %s: %v", request.Method, err)
+		}
+	}
 }
 
-// processRequest processes an MCP request
-func (s *Server) processRequest(request map[string]interface{}) (map[string]interface{}, error) {
-	// Extract method and params
-	method, ok := request["method"].(string)
-	if !ok {
-		return nil, fmt.Errorf("missing or invalid method")
+// handleCancelled handles the notifications/cancelled notification by
+// cancelling the in-flight request it names
+func (s *Server) handleCancelled(sess *session, request *Request) {
+	params, err := request.params()
+	if err != nil {
+		return
 	}
 
-	params, ok := request["params"].(map[string]interface{})
-	if !ok {
-		params = make(map[string]interface{})
+	requestID, err := json.Marshal(params["requestId"])
+	if err != nil || params["requestId"] == nil {
+		return
+	}
+
+	if sess.cancel(requestID) {
+		log.Printf("Cancelling request %s: %v", requestID, params["reason"])
+	}
+}
+
+// processRequest processes an MCP request and returns its result
+func (s *Server) processRequest(ctx context.Context, sess *session, request *Request, notify notifier) (interface{}, error) {
+	params, err := request.params()
+	if err != nil {
+		return nil, err
 	}
 
 	// Process the request based on the method
 	var result interface{}
-	var err error
 
-	switch method {
+	switch request.Method {
+	case "ping":
+		result = s.handlePing()
+	case "initialize":
+		result, err = s.handleInitialize(sess, params)
+	case "tools/list":
+		result = s.handleToolsList()
+	case "tools/call":
+		result, err = s.handleToolsCall(ctx, sess, params, notify)
+	case "resources/list":
+		result = s.handleResourcesList()
+	case "resources/read":
+		result, err = s.handleResourcesRead(params)
+	case "prompts/list":
+		result = s.handlePromptsList()
+	case "prompts/get":
+		result, err = s.handlePromptsGet(params)
+	case "logging/setLevel":
+		result, err = s.handleSetLevel(sess, params)
 	case "validate":
-		result, err = s.validator.Validate(params)
+		result, err = s.validate(ctx, params, nil)
+		if err != nil {
+			err = newError(InvalidParams, "%v", err)
+		}
 	case "validateURLPath":
-		result, err = s.validator.ValidateURLPath(params)
+		result, err = s.validator.ValidateURLPath(ctx, params)
+		if err != nil {
+			err = newError(InvalidParams, "%v", err)
+		}
+	case "listRules":
+		result, err = s.validator.ListRules(params)
+		if err != nil {
+			err = newError(InvalidParams, "%v", err)
+		}
 	case "getTools":
 		result = s.getTools()
 	case "getResources":
 		result = s.getResources()
 	default:
-		err = fmt.Errorf("unknown method: %s", method)
+		err = newError(MethodNotFound, "method not found: %s", request.Method)
 	}
 
 	if err != nil {
 		return nil, err
 	}
 
-	// Create the response
-	response := map[string]interface{}{
-		"jsonrpc": "2.0",
-		"id":      request["id"],
-		"result":  result,
-	}
-
-	return response, nil
+	return result, nil
 }
 
-// getTools returns the available tools
+// getTools returns the available tools keyed by name. It predates the MCP
+// tools/list method and is kept for existing clients.
 func (s *Server) getTools() map[string]interface{} {
-	return map[string]interface{}{
-		"validate_api": map[string]interface{}{
-			"description": "Validate a REST API against a set of rules",
-			"input_schema": map[string]interface{}{
-				"type": "object",
-				"properties": map[string]interface{}{
-					"api_spec": map[string]interface{}{
-						"type":        "string",
-						"description": "OpenAPI specification in YAML or JSON format",
-					},
-					"rules": map[string]interface{}{
-						"type":        "array",
-						"description": "List of rules to validate against",
-						"items": map[string]interface{}{
-							"type": "string",
-						},
-					},
-				},
-				"required": []string{"api_spec"},
-			},
-		},
-		"validate_url_path": map[string]interface{}{
-			"description": "Validate a single URL path against Solace REST API conventions",
-			"input_schema": map[string]interface{}{
-				"type": "object",
-				"properties": map[string]interface{}{
-					"url_path": map[string]interface{}{
-						"type":        "string",
-						"description": "URL path to validate (e.g., /api/v0/admin/cloudAgents/{datacenterId}/upgrades)",
-					},
-				},
-				"required": []string{"url_path"},
-			},
-		},
+	tools := make(map[string]interface{})
+	for _, tool := range s.toolDefinitions() {
+		tools[tool["name"].(string)] = map[string]interface{}{
+			"description":  tool["description"],
+			"input_schema": tool["inputSchema"],
+		}
 	}
+	return tools
 }
 
-// getResources returns the available resources
+// getResources returns the available resources keyed by rule name. It
+// predates the MCP resources/list method and is kept for existing clients.
 func (s *Server) getResources() map[string]interface{} {
-	return map[string]interface{}{
-		"rules": map[string]interface{}{
-			"description": "Available validation rules",
-		},
+	resources := make(map[string]interface{})
+	for _, resource := range s.handleResourcesList()["resources"].([]map[string]interface{}) {
+		resources[resource["name"].(string)] = map[string]interface{}{
+			"uri":         resource["uri"],
+			"description": resource["description"],
+		}
 	}
+	return resources
 }
 
 // keepAliveMonitor monitors the server for inactivity
@@ -254,7 +375,7 @@ func (s *Server) sendSelfPing() {
 	}
 
 	// Send the request
-	resp, err := http.Post(fmt.Sprintf("http://localhost:%d/mcp", s.port), "application/json", nil)
+	resp, err := http.Post(fmt.Sprintf("http://localhost:%d/mcp", s.port), "application/json", bytes.NewReader(requestJSON))
 	if err != nil {
 		log.Printf("Error sending ping request: %v : %v", err, requestJSON)
 		return
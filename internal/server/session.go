@@ -0,0 +1,304 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// sessionTTL is how long an idle HTTP session is kept before it expires
+	sessionTTL = time.Hour
+	// maxSessionHistory bounds the number of events kept per session for resumption
+	maxSessionHistory = 1000
+	// standaloneStream is the stream ID of the session's GET event stream
+	standaloneStream = "0"
+)
+
+// notifier delivers a server-to-client JSON-RPC message
+type notifier func(message map[string]interface{})
+
+// sseEvent is a server-sent event recorded for stream resumption
+type sseEvent struct {
+	ID     string
+	Stream string
+	Data   []byte
+}
+
+// session holds the MCP lifecycle state of a connected client. Over stdio
+// there is a single session for the lifetime of the process; over HTTP each
+// client gets a session identified by the Mcp-Session-Id header.
+type session struct {
+	id string
+
+	mu              sync.Mutex
+	protocolVersion string
+	initialized     bool
+	logLevel        string
+	lastSeen        time.Time
+
+	// out delivers messages directly to the client (stdio sessions)
+	out notifier
+
+	// inflight holds the cancel functions of requests being processed, keyed by request ID
+	inflight map[string]context.CancelFunc
+
+	// ctx is cancelled when the session is closed. Streamed requests run on
+	// it so they complete, and can be resumed, after the client disconnects.
+	ctx  context.Context
+	stop context.CancelFunc
+
+	// Event bookkeeping for HTTP event streams
+	nextStream int
+	nextEvent  int
+	history    []sseEvent
+	// streams holds the IDs of the request streams still being written
+	streams map[string]bool
+	// listeners holds the channels of the GET event streams, keyed by the
+	// ID of the stream they follow
+	listeners map[string]chan sseEvent
+}
+
+// defaultLogLevel is the log level of new sessions. Lower levels report
+// the progress of each validation and have to be asked for.
+const defaultLogLevel = "warning"
+
+// newSession creates a new session with the given ID
+func newSession(id string) *session {
+	ctx, stop := context.WithCancel(context.Background())
+	return &session{
+		id:         id,
+		logLevel:   defaultLogLevel,
+		lastSeen:   time.Now(),
+		inflight:   make(map[string]context.CancelFunc),
+		ctx:        ctx,
+		stop:       stop,
+		nextStream: 1,
+		streams:    make(map[string]bool),
+		listeners:  make(map[string]chan sseEvent),
+	}
+}
+
+// newSessionID generates a cryptographically random session ID
+func newSessionID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("error generating session ID: %v", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// touch records activity on the session
+func (s *session) touch() {
+	s.mu.Lock()
+	s.lastSeen = time.Now()
+	s.mu.Unlock()
+}
+
+// expired reports whether the session has been idle for longer than the TTL
+func (s *session) expired(now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.listeners) == 0 && len(s.streams) == 0 && now.Sub(s.lastSeen) > sessionTTL
+}
+
+// track registers the cancel function of an in-flight request
+func (s *session) track(id json.RawMessage, cancel context.CancelFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.inflight[requestKey(id)] = cancel
+}
+
+// untrack unregisters an in-flight request once it has completed
+func (s *session) untrack(id json.RawMessage) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.inflight, requestKey(id))
+}
+
+// cancel cancels an in-flight request. It reports whether the request was found.
+func (s *session) cancel(id json.RawMessage) bool {
+	s.mu.Lock()
+	cancel, ok := s.inflight[requestKey(id)]
+	s.mu.Unlock()
+
+	if ok {
+		cancel()
+	}
+	return ok
+}
+
+// requestKey normalizes a request ID so that equal IDs compare equal
+// regardless of their JSON formatting
+func requestKey(id json.RawMessage) string {
+	var value interface{}
+	if err := json.Unmarshal(id, &value); err != nil {
+		return string(id)
+	}
+	key, _ := json.Marshal(value)
+	return string(key)
+}
+
+// logs reports whether messages at the given level pass the session's log level
+func (s *session) logs(level string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return logLevelIndex(level) >= logLevelIndex(s.logLevel)
+}
+
+// notify delivers a server-to-client message outside of any request stream:
+// directly for stdio sessions, on the GET event stream for HTTP sessions
+func (s *session) notify(message map[string]interface{}) {
+	if s.out != nil {
+		s.out(message)
+		return
+	}
+
+	data, err := json.Marshal(message)
+	if err != nil {
+		log.Printf("Error encoding notification: %v", err)
+		return
+	}
+	s.publish(data)
+}
+
+// openStream allocates the ID of a new request stream
+func (s *session) openStream() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := strconv.Itoa(s.nextStream)
+	s.nextStream++
+	s.streams[id] = true
+	return id
+}
+
+// closeStream ends a request stream once its response has been recorded,
+// closing the GET event stream resuming it, if any
+func (s *session) closeStream(stream string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.streams, stream)
+	if listener, ok := s.listeners[stream]; ok {
+		close(listener)
+		delete(s.listeners, stream)
+	}
+}
+
+// record assigns an event ID to a message on the given stream, keeps it in
+// the bounded history so a disconnected client can resume, and sends it to
+// the GET event stream following the stream, if any
+func (s *session) record(stream string, data []byte) sseEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextEvent++
+	event := sseEvent{
+		ID:     fmt.Sprintf("%s-%d", stream, s.nextEvent),
+		Stream: stream,
+		Data:   data,
+	}
+
+	s.history = append(s.history, event)
+	if len(s.history) > maxSessionHistory {
+		s.history = s.history[len(s.history)-maxSessionHistory:]
+	}
+
+	if listener, ok := s.listeners[stream]; ok {
+		select {
+		case listener <- event:
+		default:
+			// The client isn't keeping up; it can resume from the history
+		}
+	}
+
+	return event
+}
+
+// resume returns the recorded events of the stream that lastEventID belongs
+// to which were sent after it. If the stream is still being written, listener
+// is attached to it in the same step, so no later event is missed, and true
+// is returned.
+func (s *session) resume(lastEventID string, listener chan sseEvent) ([]sseEvent, bool, error) {
+	parts := strings.SplitN(lastEventID, "-", 2)
+	if len(parts) != 2 {
+		return nil, false, fmt.Errorf("invalid event ID: %s", lastEventID)
+	}
+	seq, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid event ID: %s", lastEventID)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var events []sseEvent
+	for _, event := range s.history {
+		if event.Stream != parts[0] {
+			continue
+		}
+		if n, _ := strconv.Atoi(strings.SplitN(event.ID, "-", 2)[1]); n > seq {
+			events = append(events, event)
+		}
+	}
+
+	if parts[0] != standaloneStream && !s.streams[parts[0]] {
+		return events, false, nil
+	}
+	s.attachLocked(parts[0], listener)
+	return events, true, nil
+}
+
+// attach registers the channel of a GET event stream following the given
+// stream, replacing (and closing) any previous one
+func (s *session) attach(stream string, listener chan sseEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attachLocked(stream, listener)
+}
+
+// attachLocked is attach for callers holding the session lock
+func (s *session) attachLocked(stream string, listener chan sseEvent) {
+	if previous, ok := s.listeners[stream]; ok {
+		close(previous)
+	}
+	s.listeners[stream] = listener
+}
+
+// detach unregisters the given GET event stream channel
+func (s *session) detach(listener chan sseEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for stream, attached := range s.listeners {
+		if attached == listener {
+			close(listener)
+			delete(s.listeners, stream)
+		}
+	}
+}
+
+// close terminates the session, the requests streamed on it and its GET
+// event streams
+func (s *session) close() {
+	s.stop()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for stream, listener := range s.listeners {
+		close(listener)
+		delete(s.listeners, stream)
+	}
+}
+
+// publish sends a message on the session's standalone GET event stream.
+// Messages are recorded even when no stream is attached so they can be
+// resumed.
+func (s *session) publish(data []byte) {
+	s.record(standaloneStream, data)
+}
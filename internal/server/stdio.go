@@ -0,0 +1,88 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+)
+
+// StdioTransport serves MCP requests as newline-delimited JSON-RPC messages
+// over a reader/writer pair, typically the process stdin and stdout.
+type StdioTransport struct {
+	server  *Server
+	session *session
+	in      io.Reader
+	out     io.Writer
+	mu      sync.Mutex
+}
+
+// NewStdioTransport creates a new stdio transport for the given server
+func NewStdioTransport(s *Server, in io.Reader, out io.Writer) *StdioTransport {
+	t := &StdioTransport{
+		server:  s,
+		session: newSession(""),
+		in:      in,
+		out:     out,
+	}
+	t.session.out = func(message map[string]interface{}) {
+		t.write(message)
+	}
+	return t
+}
+
+// Serve reads requests until the input is closed. Each line must hold a
+// single JSON-RPC message; responses are written back one per line.
+// Messages are handled concurrently so that a long validation doesn't block
+// later messages such as cancellations.
+func (t *StdioTransport) Serve() error {
+	reader := bufio.NewReader(t.in)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			wg.Add(1)
+			go func(line []byte) {
+				defer wg.Done()
+				t.handleLine(line)
+			}(line)
+		}
+
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("error reading from stdin: %v", err)
+		}
+	}
+}
+
+// handleLine handles a single message and writes its response
+func (t *StdioTransport) handleLine(line []byte) {
+	response := t.server.handleMessage(context.Background(), t.session, line, t.session.notify)
+	if response != nil {
+		t.write(response)
+	}
+}
+
+// write encodes a message as a single line on the output stream
+func (t *StdioTransport) write(message interface{}) {
+	data, err := json.Marshal(message)
+	if err != nil {
+		log.Printf("Error encoding response: %v", err)
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, err := t.out.Write(append(data, '\n')); err != nil {
+		log.Printf("Error writing response: %v", err)
+	}
+}
@@ -0,0 +1,297 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// sessionHeader carries the MCP session ID on the Streamable HTTP transport
+	sessionHeader = "Mcp-Session-Id"
+	// eventStreamHeartbeat is the interval of keep-alive comments on event streams
+	eventStreamHeartbeat = 30 * time.Second
+)
+
+// lookupSession resolves the session named by the request's Mcp-Session-Id
+// header. It returns nil without error when the header is absent.
+func (s *Server) lookupSession(r *http.Request) (*session, error) {
+	id := r.Header.Get(sessionHeader)
+	if id == "" {
+		return nil, nil
+	}
+
+	s.sessionsMu.Lock()
+	defer s.sessionsMu.Unlock()
+
+	sess, ok := s.sessions[id]
+	if !ok {
+		return nil, fmt.Errorf("unknown session: %s", id)
+	}
+	return sess, nil
+}
+
+// createSession registers a new HTTP session
+func (s *Server) createSession() (*session, error) {
+	id, err := newSessionID()
+	if err != nil {
+		return nil, err
+	}
+
+	sess := newSession(id)
+
+	s.sessionsMu.Lock()
+	s.sessions[id] = sess
+	s.sessionsMu.Unlock()
+
+	return sess, nil
+}
+
+// removeSession unregisters and closes an HTTP session
+func (s *Server) removeSession(sess *session) {
+	s.sessionsMu.Lock()
+	delete(s.sessions, sess.id)
+	s.sessionsMu.Unlock()
+
+	sess.close()
+}
+
+// sessionReaper periodically removes idle sessions
+func (s *Server) sessionReaper() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case now := <-ticker.C:
+			s.sessionsMu.Lock()
+			var expired []*session
+			for _, sess := range s.sessions {
+				if sess.expired(now) {
+					expired = append(expired, sess)
+				}
+			}
+			s.sessionsMu.Unlock()
+
+			for _, sess := range expired {
+				log.Printf("Session %s expired", sess.id)
+				s.removeSession(sess)
+			}
+		}
+	}
+}
+
+// acceptsEventStream reports whether the client accepts a text/event-stream response
+func acceptsEventStream(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), "text/event-stream")
+}
+
+// writeEvent writes a server-sent event and flushes it to the client
+func writeEvent(w http.ResponseWriter, event sseEvent) {
+	fmt.Fprintf(w, "id: %s\nevent: message\ndata: %s\n\n", event.ID, event.Data)
+	if flusher, ok := w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// handlePost handles a client message sent with POST. An initialize request
+// starts a new session; requests without a session header are served
+// statelessly for legacy clients. Tool calls are answered with an event
+// stream when the client accepts one, so partial results can be streamed.
+func (s *Server) handlePost(w http.ResponseWriter, r *http.Request) {
+	sess, err := s.lookupSession(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error reading request: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	// Peek at single requests; batches are never streamed and can't initialize
+	var request Request
+	single := json.Unmarshal(body, &request) == nil
+
+	switch {
+	case sess == nil && single && request.Method == "initialize":
+		if sess, err = s.createSession(); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set(sessionHeader, sess.id)
+	case sess == nil:
+		sess = newSession("")
+	}
+	sess.touch()
+
+	// Stream the response of tool calls when the client accepts it
+	if single && request.Method == "tools/call" && !request.IsNotification() && acceptsEventStream(r) {
+		s.streamResponse(w, r, sess, body)
+		return
+	}
+
+	// Handle the message and send the response
+	response := s.handleMessage(r.Context(), sess, body, sess.notify)
+	if response == nil {
+		// Notifications are acknowledged without a body
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	status := http.StatusOK
+	if single, ok := response.(*Response); ok && single.Error != nil {
+		switch {
+		case single.Error.Code == ParseError:
+			status = http.StatusBadRequest
+		case request.Method == "initialize" && sess.id != "":
+			// Drop sessions whose initialization failed
+			s.removeSession(sess)
+			w.Header().Del(sessionHeader)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
+
+// streamResponse answers a request with an event stream carrying the
+// messages sent while handling it, followed by the response itself. Requests
+// of a session run on its context rather than the request's, so they complete
+// when the client disconnects and the client can resume the stream to get
+// the response.
+func (s *Server) streamResponse(w http.ResponseWriter, r *http.Request, sess *session, body []byte) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	ctx := r.Context()
+	if sess.id != "" {
+		ctx = sess.ctx
+	}
+
+	stream := sess.openStream()
+	defer sess.closeStream(stream)
+
+	var mu sync.Mutex
+	write := func(message interface{}) {
+		data, err := json.Marshal(message)
+		if err != nil {
+			log.Printf("Error encoding message: %v", err)
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		event := sess.record(stream, data)
+		if r.Context().Err() == nil {
+			writeEvent(w, event)
+		}
+	}
+
+	send := func(message map[string]interface{}) {
+		write(message)
+	}
+	if response := s.handleMessage(ctx, sess, body, send); response != nil {
+		write(response)
+	}
+}
+
+// handleEventStream handles GET requests by opening the session's event
+// stream for server-to-client messages. A Last-Event-ID header resumes a
+// stream by replaying the events sent after it, then follows the stream
+// until its response has been sent.
+func (s *Server) handleEventStream(w http.ResponseWriter, r *http.Request) {
+	if !acceptsEventStream(r) {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	sess, err := s.lookupSession(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if sess == nil {
+		http.Error(w, fmt.Sprintf("Missing %s header", sessionHeader), http.StatusBadRequest)
+		return
+	}
+
+	listener := make(chan sseEvent, 64)
+	var missed []sseEvent
+	attached := true
+	if lastEventID := r.Header.Get("Last-Event-ID"); lastEventID != "" {
+		if missed, attached, err = sess.resume(lastEventID, listener); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	} else {
+		sess.attach(standaloneStream, listener)
+	}
+	if attached {
+		defer sess.detach(listener)
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	for _, event := range missed {
+		writeEvent(w, event)
+	}
+	if !attached {
+		// The stream has ended and its response was among the missed events
+		return
+	}
+
+	heartbeat := time.NewTicker(eventStreamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case event, ok := <-listener:
+			if !ok {
+				// Replaced by a newer stream, the resumed stream has ended
+				// or the session was closed
+				return
+			}
+			writeEvent(w, event)
+		case <-heartbeat.C:
+			sess.touch()
+			fmt.Fprint(w, ": keep-alive\n\n")
+			if flusher, ok := w.(http.Flusher); ok {
+				flusher.Flush()
+			}
+		case <-r.Context().Done():
+			return
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// handleDeleteSession handles DELETE requests by terminating the session
+func (s *Server) handleDeleteSession(w http.ResponseWriter, r *http.Request) {
+	sess, err := s.lookupSession(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if sess == nil {
+		http.Error(w, fmt.Sprintf("Missing %s header", sessionHeader), http.StatusBadRequest)
+		return
+	}
+
+	s.removeSession(sess)
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,143 @@
+package validator
+
+import (
+	"context"
+	"log"
+	"runtime/debug"
+	"time"
+
+	"github.com/solacedev/restv2-api-server-go/internal/openapi"
+	"github.com/solacedev/restv2-api-server-go/internal/rules"
+)
+
+// defaultRuleTimeout is the time a single rule may run before it is reported
+// as an error
+const defaultRuleTimeout = 30 * time.Second
+
+// ruleOutcome is the result of applying one rule
+type ruleOutcome struct {
+	name   string
+	result *rules.Result
+}
+
+// applyRules applies rules to the document on a bounded pool of workers.
+// Results are reported to onResult, when set, in the order rules complete.
+// Rules only read the document, so they can share it. All rules are taken
+// from the same rule set, even if rules are reloaded meanwhile. The
+// severities of the selection override those of the rules' issues, and
+// issues covered by the suppressions are marked as such.
+func (v *Validator) applyRules(ctx context.Context, doc *openapi.Document, selection rules.Selection, suppressions []rules.Suppression, onResult ResultHandler) (map[string]*rules.Result, error) {
+	ruleSet := v.ruleSet()
+	ruleNames := selection.Rules
+
+	workers := v.workers
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > len(ruleNames) {
+		workers = len(ruleNames)
+	}
+
+	jobs := make(chan string)
+	// Buffered so workers never block once the caller has stopped listening
+	outcomes := make(chan ruleOutcome, len(ruleNames))
+
+	go func() {
+		defer close(jobs)
+		for _, name := range ruleNames {
+			select {
+			case jobs <- name:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	for i := 0; i < workers; i++ {
+		go func() {
+			for name := range jobs {
+				result := v.applyRule(ctx, doc, name, ruleSet[name])
+				if severity, ok := selection.Severities[name]; ok {
+					result = rules.OverrideSeverity(result, severity)
+				}
+				result = rules.Suppress(doc, result, suppressions)
+				outcomes <- ruleOutcome{name: name, result: result}
+			}
+		}()
+	}
+
+	results := make(map[string]*rules.Result, len(ruleNames))
+	for completed := 1; completed <= len(ruleNames); completed++ {
+		select {
+		case outcome := <-outcomes:
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			results[outcome.name] = outcome.result
+			if onResult != nil {
+				onResult(outcome.name, outcome.result, completed, len(ruleNames))
+			}
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	return results, nil
+}
+
+// applyRule applies a single rule with its own timeout. Rules that fail,
+// panic or run past the timeout are reported as an error result. A rule that
+// ignores its context can't be stopped, so it is abandoned and left to finish
+// in the background.
+func (v *Validator) applyRule(ctx context.Context, doc *openapi.Document, name string, rule rules.Rule) *rules.Result {
+	if rule == nil {
+		return rules.ErrorResult("rule not found: %s", name)
+	}
+
+	ruleCtx, cancel := context.WithTimeout(ctx, v.ruleTimeout)
+	defer cancel()
+
+	type applied struct {
+		result *rules.Result
+		err    error
+		panic  interface{}
+	}
+	done := make(chan applied, 1)
+
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				log.Printf("Rule %s panicked: %v\n%s", name, p, debug.Stack())
+				done <- applied{panic: p}
+			}
+		}()
+
+		result, err := rule.Apply(ruleCtx, doc)
+		done <- applied{result: result, err: err}
+	}()
+
+	select {
+	case a := <-done:
+		switch {
+		case a.panic != nil:
+			return rules.ErrorResult("rule panicked: %v", a.panic)
+		case a.err != nil && ruleCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil:
+			return rules.ErrorResult("rule timed out after %s", v.ruleTimeout)
+		case a.err != nil:
+			return rules.ErrorResult("error applying rule: %v", a.err)
+		case a.result == nil:
+			return rules.ErrorResult("rule returned no result")
+		}
+
+		rules.SortIssues(a.result.Issues)
+		fingerprintIssues(doc, a.result.Issues)
+		annotateIssues(rule, a.result.Issues)
+		return a.result
+	case <-ruleCtx.Done():
+		if ctx.Err() != nil {
+			return rules.ErrorResult("error applying rule: %v", ctx.Err())
+		}
+		log.Printf("Rule %s timed out after %s", name, v.ruleTimeout)
+		return rules.ErrorResult("rule timed out after %s", v.ruleTimeout)
+	}
+}
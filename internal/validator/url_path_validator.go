@@ -1,6 +1,7 @@
 package validator
 
 import (
+	"context"
 	"fmt"
 	"strings"
 )
@@ -18,7 +19,7 @@ func NewURLPathValidator(validator *Validator) *URLPathValidator {
 }
 
 // ValidateURLPath validates a single URL path against Solace REST API conventions
-func (v *URLPathValidator) ValidateURLPath(urlPath string) (map[string]interface{}, error) {
+func (v *URLPathValidator) ValidateURLPath(ctx context.Context, urlPath string) (map[string]interface{}, error) {
 	// Extract path parameters
 	pathParams := v.extractPathParams(urlPath)
 
@@ -36,7 +37,7 @@ func (v *URLPathValidator) ValidateURLPath(urlPath string) (map[string]interface
 		"api_spec": spec,
 	}
 
-	results, err := v.validator.Validate(params)
+	results, err := v.validator.Validate(ctx, params)
 	if err != nil {
 		return nil, fmt.Errorf("error validating URL path: %v", err)
 	}
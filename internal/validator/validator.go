@@ -1,35 +1,63 @@
 package validator
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io/ioutil"
+	"log"
 	"os"
-	"strings"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
 
+	"github.com/solacedev/restv2-api-server-go/config"
+	"github.com/solacedev/restv2-api-server-go/internal/openapi"
 	"github.com/solacedev/restv2-api-server-go/internal/rules"
-	"gopkg.in/yaml.v3"
 )
 
 // Validator represents the REST API validator
 type Validator struct {
+	// mu guards the rule set and the profiles, which are replaced as a whole
+	// on reload and never modified in place
+	mu               sync.RWMutex
 	rules            map[string]rules.Rule
+	profiles         map[string]*rules.Profile
 	urlPathValidator *URLPathValidator
+
+	// rulesDirs are the directories JSON rules are loaded from on top of
+	// the embedded defaults, in increasing order of precedence
+	rulesDirs []string
+	// workers bounds the number of rules applied concurrently
+	workers int
+	// ruleTimeout bounds the time a single rule may run
+	ruleTimeout time.Duration
+	// generation is incremented whenever the set of rules changes
+	generation uint64
 }
 
-// NewValidator creates a new validator instance
-func NewValidator() (*Validator, error) {
+// NewValidator creates a new validator instance. The built-in rules and the
+// default JSON rules embedded in the binary are always loaded; the JSON
+// rules of the given directories are layered on top of them, rules of later
+// directories replacing earlier rules with the same name.
+func NewValidator(rulesDirs ...string) (*Validator, error) {
 	v := &Validator{
-		rules: make(map[string]rules.Rule),
+		rulesDirs:   rulesDirs,
+		workers:     runtime.NumCPU(),
+		ruleTimeout: defaultRuleTimeout,
 	}
 
-	// Register built-in rules
-	v.registerBuiltInRules()
-
-	// Register JSON rules
-	if err := v.registerJSONRules("config/rules"); err != nil {
-		// Log the error but continue
-		fmt.Printf("Warning: Error loading JSON rules: %v\n", err)
+	// Load the rules
+	ruleSet, profiles, err := v.loadRules()
+	if err != nil {
+		return nil, fmt.Errorf("error loading rules: %v", err)
 	}
+	v.rules = ruleSet
+	v.profiles = profiles
+	v.generation = 1
 
 	// Initialize URL path validator
 	v.urlPathValidator = NewURLPathValidator(v)
@@ -37,32 +65,179 @@ func NewValidator() (*Validator, error) {
 	return v, nil
 }
 
-// registerJSONRules registers rules from JSON files in the specified directory
-func (v *Validator) registerJSONRules(dirPath string) error {
-	// Load JSON rules
-	jsonRules, err := rules.LoadJSONRulesFromDir(dirPath)
+// loadRules loads the built-in rules, the embedded default JSON rules and
+// profiles, and the JSON rules and profiles of the rules directories, in
+// increasing order of precedence
+func (v *Validator) loadRules() (map[string]rules.Rule, map[string]*rules.Profile, error) {
+	ruleSet := make(map[string]rules.Rule)
+	profiles := make(map[string]*rules.Profile)
+	v.registerBuiltInRules(ruleSet)
+
+	defaults, err := rules.LoadJSONRulesFromFS(config.DefaultRules, config.DefaultRulesDir, "embedded")
 	if err != nil {
-		return fmt.Errorf("error loading JSON rules: %v", err)
+		return nil, nil, err
+	}
+	v.registerJSONRules(ruleSet, defaults)
+
+	defaultProfiles, err := rules.LoadProfilesFromFS(config.DefaultRules, config.DefaultRulesDir, "embedded")
+	if err != nil {
+		return nil, nil, err
+	}
+	registerProfiles(profiles, defaultProfiles)
+
+	for _, dirPath := range v.rulesDirs {
+		jsonRules, err := rules.LoadJSONRulesFromDir(dirPath)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error loading JSON rules from %s: %v", dirPath, err)
+		}
+		v.registerJSONRules(ruleSet, jsonRules)
+
+		dirProfiles, err := rules.LoadProfilesFromDir(dirPath)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error loading profiles from %s: %v", dirPath, err)
+		}
+		registerProfiles(profiles, dirProfiles)
 	}
 
-	// Register the rules
-	for name, rule := range jsonRules {
-		v.rules[name] = rule
+	// Catch broken profiles now rather than when they are used
+	for _, name := range sortedProfileNames(profiles) {
+		if _, err := rules.ResolveProfile(name, profiles, ruleSet); err != nil {
+			return nil, nil, fmt.Errorf("invalid profile %s: %v", name, err)
+		}
 	}
 
-	return nil
+	return ruleSet, profiles, nil
+}
+
+// registerJSONRules registers JSON rules, replacing the JSON rules of the
+// same name. Built-in rules take precedence: a leftover JSON rule named after
+// a rule that moved to Go is skipped, so it can't disable the built-in checks.
+func (v *Validator) registerJSONRules(ruleSet map[string]rules.Rule, jsonRules map[string]rules.Rule) {
+	for _, name := range sortedNames(jsonRules) {
+		if existing, ok := ruleSet[name]; ok {
+			if _, isJSON := existing.(*rules.JSONRule); !isJSON {
+				log.Printf("Skipping %s: rule %s has the name of a built-in rule", jsonRules[name].(*rules.JSONRule).FilePath, name)
+				continue
+			}
+		}
+		ruleSet[name] = jsonRules[name]
+	}
+}
+
+// registerProfiles registers profiles, replacing the profiles of the same name
+func registerProfiles(profiles map[string]*rules.Profile, loaded map[string]*rules.Profile) {
+	for name, profile := range loaded {
+		profiles[name] = profile
+	}
 }
 
 // registerBuiltInRules registers the built-in validation rules
-func (v *Validator) registerBuiltInRules() {
+func (v *Validator) registerBuiltInRules(ruleSet map[string]rules.Rule) {
 	// Register Solace REST rules
-	v.rules["solace_rest_rules"] = rules.NewSolaceRestRules()
-	v.rules["solace_singular_user_resources"] = rules.NewSolaceSingularUserResourcesRule()
-	v.rules["solace_custom_actions"] = rules.NewSolaceCustomActionsRule()
+	ruleSet["solace_rest_rules"] = rules.NewSolaceRestRules()
+	ruleSet["solace_singular_user_resources"] = rules.NewSolaceSingularUserResourcesRule()
+	ruleSet["solace_custom_actions"] = rules.NewSolaceCustomActionsRule()
+
+	// Register the ADR rules that need more than JSON conditions
+	ruleSet["pagination"] = rules.NewPaginationRule()
+	ruleSet["error_responses"] = rules.NewErrorResponsesRule()
+	ruleSet["payload_structure"] = rules.NewPayloadStructureRule()
+	ruleSet["standard_fields"] = rules.NewStandardFieldsRule()
+	ruleSet["audit_fields"] = rules.NewAuditFieldsRule()
+	ruleSet["delete_behavior"] = rules.NewDeleteBehaviorRule()
 }
 
+// RulesDirs returns the directories JSON rules are loaded from on top of
+// the embedded defaults
+func (v *Validator) RulesDirs() []string {
+	return v.rulesDirs
+}
+
+// ReloadRules reloads the JSON rules from the rules directories and swaps
+// them in atomically. It reports whether the rule set changed. When a rule
+// file can't be loaded, the current rules are kept and the error returned.
+func (v *Validator) ReloadRules() (bool, error) {
+	ruleSet, profiles, err := v.loadRules()
+	if err != nil {
+		return false, err
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if rulesVersion(ruleSet, profiles) == rulesVersion(v.rules, v.profiles) {
+		return false, nil
+	}
+	v.rules = ruleSet
+	v.profiles = profiles
+	v.generation++
+	return true, nil
+}
+
+// rulesVersion identifies a rule set and a set of profiles by the versions
+// of their definitions
+func rulesVersion(ruleSet map[string]rules.Rule, profiles map[string]*rules.Profile) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00", rules.Selection{Rules: sortedNames(ruleSet)}.Version(ruleSet))
+	for _, name := range sortedProfileNames(profiles) {
+		fmt.Fprintf(h, "%s\x00%s\x00", name, profiles[name].Version)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// ruleSet returns the current set of rules. The returned map must not be
+// modified; it stays consistent while rules are reloaded.
+func (v *Validator) ruleSet() map[string]rules.Rule {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.rules
+}
+
+// rulesAndProfiles returns the current set of rules and profiles, which
+// must not be modified
+func (v *Validator) rulesAndProfiles() (map[string]rules.Rule, map[string]*rules.Profile) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.rules, v.profiles
+}
+
+// Generation identifies the current set of rules. It changes whenever rules
+// are reloaded, so results computed with an older set can be discarded.
+func (v *Validator) Generation() uint64 {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.generation
+}
+
+// ResultHandler receives the result of each rule as soon as it has been
+// applied, along with the number of rules completed so far and in total
+type ResultHandler func(ruleName string, result *rules.Result, completed, total int)
+
 // Validate validates an API specification against a set of rules
-func (v *Validator) Validate(params map[string]interface{}) (map[string]interface{}, error) {
+func (v *Validator) Validate(ctx context.Context, params map[string]interface{}) (map[string]interface{}, error) {
+	return v.ValidateStream(ctx, params, nil)
+}
+
+// ValidateStream validates an API specification against a set of rules and
+// reports each rule's result to onResult, when set, as it becomes available.
+// Validation stops with the context's error once it is cancelled.
+func (v *Validator) ValidateStream(ctx context.Context, params map[string]interface{}, onResult ResultHandler) (map[string]interface{}, error) {
+	doc, err := v.ParseParams(params)
+	if err != nil {
+		return nil, err
+	}
+
+	selection, err := v.SelectRules(params)
+	if err != nil {
+		return nil, err
+	}
+
+	return v.ValidateDocument(ctx, doc, selection, onResult)
+}
+
+// ParseParams parses and resolves the API specification passed in the
+// api_spec parameter
+func (v *Validator) ParseParams(params map[string]interface{}) (*openapi.Document, error) {
 	// Extract API spec from params
 	apiSpec, ok := params["api_spec"].(string)
 	if !ok {
@@ -70,62 +245,185 @@ func (v *Validator) Validate(params map[string]interface{}) (map[string]interfac
 	}
 
 	// Parse the API spec
-	spec, err := v.parseAPISpec(apiSpec)
+	doc, err := v.parseAPISpec(apiSpec)
 	if err != nil {
 		return nil, fmt.Errorf("error parsing API spec: %v", err)
 	}
+	return doc, nil
+}
 
-	// Extract rules to validate against
-	var rulesToApply []string
-	if rulesParam, ok := params["rules"].([]interface{}); ok {
-		for _, r := range rulesParam {
-			if ruleName, ok := r.(string); ok {
-				rulesToApply = append(rulesToApply, ruleName)
-			}
+// SelectRules returns the selection of rules made by the profile parameter,
+// or of all available rules when no profile is given. The rules parameter
+// narrows the selection down to the requested rules, and the tags parameter
+// to the rules with any of the requested tags. Rules are returned in sorted
+// order so the output is the same from run to run.
+func (v *Validator) SelectRules(params map[string]interface{}) (rules.Selection, error) {
+	ruleSet, profiles := v.rulesAndProfiles()
+
+	selection := rules.Selection{Rules: sortedNames(ruleSet)}
+	if profileParam, ok := params["profile"]; ok && profileParam != nil {
+		profile, ok := profileParam.(string)
+		if !ok || profile == "" {
+			return rules.Selection{}, fmt.Errorf("invalid profile parameter")
+		}
+
+		var err error
+		selection, err = rules.ResolveProfile(profile, profiles, ruleSet)
+		if err != nil {
+			return rules.Selection{}, err
 		}
 	}
 
-	// If no rules specified, use all available rules
-	if len(rulesToApply) == 0 {
-		for ruleName := range v.rules {
-			rulesToApply = append(rulesToApply, ruleName)
+	if tags := stringParams(params, "tags"); len(tags) > 0 {
+		var ruleNames []string
+		for _, ruleName := range selection.Rules {
+			if rule, ok := ruleSet[ruleName]; ok && rules.HasAnyTag(rule, tags) {
+				ruleNames = append(ruleNames, ruleName)
+			}
 		}
+		selection.Rules = ruleNames
 	}
 
-	// Apply the rules
-	results := make(map[string]interface{})
-	for _, ruleName := range rulesToApply {
-		rule, ok := v.rules[ruleName]
-		if !ok {
-			results[ruleName] = map[string]interface{}{
-				"status":  "error",
-				"message": fmt.Sprintf("rule not found: %s", ruleName),
+	requested := make(map[string]bool)
+	for _, ruleName := range stringParams(params, "rules") {
+		requested[ruleName] = true
+	}
+
+	if len(requested) == 0 {
+		return selection, nil
+	}
+
+	// Without a profile or tags, requested rules are applied even if unknown
+	// so that they are reported as such
+	var ruleNames []string
+	if selection.Profile == "" && len(stringParams(params, "tags")) == 0 {
+		for ruleName := range requested {
+			ruleNames = append(ruleNames, ruleName)
+		}
+		sort.Strings(ruleNames)
+	} else {
+		for _, ruleName := range selection.Rules {
+			if requested[ruleName] {
+				ruleNames = append(ruleNames, ruleName)
 			}
-			continue
 		}
+	}
+	selection.Rules = ruleNames
+	return selection, nil
+}
 
-		// Apply the rule
-		ruleResult, err := rule.Apply(spec)
-		if err != nil {
-			results[ruleName] = map[string]interface{}{
-				"status":  "error",
-				"message": fmt.Sprintf("error applying rule: %v", err),
+// stringParams returns the strings of an array parameter
+func stringParams(params map[string]interface{}, name string) []string {
+	var values []string
+	if param, ok := params[name].([]interface{}); ok {
+		for _, p := range param {
+			if value, ok := p.(string); ok {
+				values = append(values, value)
 			}
-			continue
 		}
+	}
+	return values
+}
 
-		results[ruleName] = ruleResult
+// ListRules returns the catalog entries of the rules selected by the
+// profile, rules and tags parameters, as for a validation
+func (v *Validator) ListRules(params map[string]interface{}) (map[string]interface{}, error) {
+	selection, err := v.SelectRules(params)
+	if err != nil {
+		return nil, err
+	}
+
+	ruleSet := v.ruleSet()
+	entries := []map[string]interface{}{}
+	for _, ruleName := range selection.Rules {
+		rule, ok := ruleSet[ruleName]
+		if !ok {
+			continue
+		}
+		entry := rules.Describe(rule)
+		if severity, ok := selection.Severities[ruleName]; ok {
+			entry["severity"] = severity
+		}
+		entries = append(entries, entry)
 	}
 
 	return map[string]interface{}{
-		"results": results,
+		"rules": entries,
 	}, nil
 }
 
-// parseAPISpec parses an API specification from a string or file path
-func (v *Validator) parseAPISpec(apiSpec string) (map[string]interface{}, error) {
+// ValidateDocument validates a parsed API specification against a
+// selection of rules, reporting each rule's result to onResult, when set
+func (v *Validator) ValidateDocument(ctx context.Context, doc *openapi.Document, selection rules.Selection, onResult ResultHandler) (map[string]interface{}, error) {
+	// Apply the rules
+	suppressions, suppressionProblems := rules.FindSuppressions(doc)
+	results, err := v.applyRules(ctx, doc, selection, suppressions, onResult)
+	if err != nil {
+		return nil, err
+	}
+
+	ruleSet := v.ruleSet()
+	categories := make(map[string]string, len(selection.Rules))
+	for _, ruleName := range selection.Rules {
+		if rule, ok := ruleSet[ruleName]; ok {
+			categories[ruleName] = rules.RuleMetadata(rule).Category
+		}
+	}
+
+	response := map[string]interface{}{
+		"results":    results,
+		"summary":    rules.Summarize(results),
+		"categories": rules.SummarizeByCategory(results, categories),
+	}
+	if selection.Profile != "" {
+		response["profile"] = selection.Profile
+	}
+	if len(doc.Problems) > 0 {
+		response["reference_errors"] = doc.Problems
+	}
+	if len(suppressionProblems) > 0 {
+		response["suppression_errors"] = suppressionProblems
+	}
+
+	return response, nil
+}
+
+// RuleSetVersion identifies a selection of rules and the versions of their
+// definitions. Unknown rules are included by name.
+func (v *Validator) RuleSetVersion(selection rules.Selection) string {
+	return selection.Version(v.ruleSet())
+}
+
+// fingerprintIssues sets the fingerprint of each issue. Files are made
+// relative to the directory of the spec so fingerprints don't depend on
+// where it is checked out.
+func fingerprintIssues(doc *openapi.Document, issues []rules.Issue) {
+	for i := range issues {
+		file := issues[i].Location.File
+		if file != "" && doc.File != "" {
+			if rel, err := filepath.Rel(filepath.Dir(doc.File), file); err == nil {
+				file = filepath.ToSlash(rel)
+			}
+		}
+		issues[i].Fingerprint = rules.Fingerprint(issues[i], file)
+	}
+}
+
+// annotateIssues sets the category and ADR link of each issue from the
+// metadata of the rule that reported it
+func annotateIssues(rule rules.Rule, issues []rules.Issue) {
+	metadata := rules.RuleMetadata(rule)
+	for i := range issues {
+		issues[i].Category = metadata.Category
+		issues[i].ADRLink = metadata.ADRLink
+	}
+}
+
+// parseAPISpec parses an API specification from a string or file path and
+// resolves its references
+func (v *Validator) parseAPISpec(apiSpec string) (*openapi.Document, error) {
 	var specContent []byte
-	var err error
+	var specFile string
 
 	// Check if apiSpec is a file path
 	if _, err := os.Stat(apiSpec); err == nil {
@@ -134,39 +432,67 @@ func (v *Validator) parseAPISpec(apiSpec string) (map[string]interface{}, error)
 		if err != nil {
 			return nil, fmt.Errorf("error reading API spec file: %v", err)
 		}
+		specFile = apiSpec
 	} else {
 		// Treat as raw content
 		specContent = []byte(apiSpec)
 	}
 
-	// Determine if it's JSON or YAML based on the file extension or content
-	var spec map[string]interface{}
-	if strings.HasSuffix(apiSpec, ".json") || (len(specContent) > 0 && specContent[0] == '{') {
-		// Parse as JSON
-		err = yaml.Unmarshal(specContent, &spec)
-	} else {
-		// Parse as YAML
-		err = yaml.Unmarshal(specContent, &spec)
-	}
-
+	// Relative references in inline content resolve against the working directory
+	baseDir, err := os.Getwd()
 	if err != nil {
-		return nil, fmt.Errorf("error parsing API spec: %v", err)
+		baseDir = "."
 	}
 
-	return spec, nil
+	return openapi.Load(specContent, specFile, baseDir)
 }
 
-// GetRules returns the available rules
+// GetRules returns the names of the available rules in sorted order
 func (v *Validator) GetRules() []string {
+	return sortedNames(v.ruleSet())
+}
+
+// sortedNames returns the names of the rules of a rule set in sorted order
+func sortedNames(ruleSet map[string]rules.Rule) []string {
 	var ruleNames []string
-	for name := range v.rules {
+	for name := range ruleSet {
 		ruleNames = append(ruleNames, name)
 	}
+	sort.Strings(ruleNames)
 	return ruleNames
 }
 
+// GetProfiles returns the names of the available profiles in sorted order
+func (v *Validator) GetProfiles() []string {
+	_, profiles := v.rulesAndProfiles()
+	return sortedProfileNames(profiles)
+}
+
+// GetProfile returns the profile registered under the given name
+func (v *Validator) GetProfile(name string) (*rules.Profile, bool) {
+	_, profiles := v.rulesAndProfiles()
+	profile, ok := profiles[name]
+	return profile, ok
+}
+
+// sortedProfileNames returns the names of a set of profiles in sorted order
+func sortedProfileNames(profiles map[string]*rules.Profile) []string {
+	var names []string
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// GetRule returns the rule registered under the given name
+func (v *Validator) GetRule(name string) (rules.Rule, bool) {
+	rule, ok := v.ruleSet()[name]
+	return rule, ok
+}
+
 // ValidateURLPath validates a single URL path against Solace REST API conventions
-func (v *Validator) ValidateURLPath(params map[string]interface{}) (map[string]interface{}, error) {
+func (v *Validator) ValidateURLPath(ctx context.Context, params map[string]interface{}) (map[string]interface{}, error) {
 	// Extract URL path from params
 	urlPath, ok := params["url_path"].(string)
 	if !ok {
@@ -174,5 +500,5 @@ func (v *Validator) ValidateURLPath(params map[string]interface{}) (map[string]i
 	}
 
 	// Validate the URL path
-	return v.urlPathValidator.ValidateURLPath(urlPath)
+	return v.urlPathValidator.ValidateURLPath(ctx, urlPath)
 }